@@ -26,17 +26,20 @@ import (
 	"net/url"
 	"path"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opencensus.io/tag"
 
 	"go.opencensus.io/internal"
+	"go.opencensus.io/internal/retry"
 
 	"go.opencensus.io/stats"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	gax "github.com/googleapis/gax-go"
 	"google.golang.org/api/option"
 	"google.golang.org/api/support/bundler"
 	distributionpb "google.golang.org/genproto/googleapis/api/distribution"
@@ -50,6 +53,17 @@ import (
 
 const maxTimeSeriesPerUpload = 200
 
+// DroppedPointCount is the number of points the exporter failed to upload
+// to Stackdriver Monitoring after retries.
+var DroppedPointCount *stats.MeasureInt64
+
+func init() {
+	var err error
+	if DroppedPointCount, err = stats.NewMeasureInt64("/opencensus.io/exporter/stackdriver/dropped_points", "Points dropped by the Stackdriver stats exporter", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/exporter/stackdriver/dropped_points: %v", err)
+	}
+}
+
 // Exporter exports stats to the Stackdriver Monitoring.
 type Exporter struct {
 	bundler *bundler.Bundler
@@ -58,7 +72,36 @@ type Exporter struct {
 	createdViewsMu sync.Mutex
 	createdViews   map[string]*metricpb.MetricDescriptor // Views already created remotely
 
-	c *monitoring.MetricClient
+	c       metricClient
+	backoff retry.Backoff
+}
+
+// metricClient is the subset of the Stackdriver Monitoring client
+// used by the exporter; it can be replaced for tests.
+type metricClient interface {
+	GetMetricDescriptor(ctx context.Context, req *monitoringpb.GetMetricDescriptorRequest, opts ...gax.CallOption) (*metricpb.MetricDescriptor, error)
+	CreateMetricDescriptor(ctx context.Context, req *monitoringpb.CreateMetricDescriptorRequest, opts ...gax.CallOption) (*metricpb.MetricDescriptor, error)
+	CreateTimeSeries(ctx context.Context, req *monitoringpb.CreateTimeSeriesRequest, opts ...gax.CallOption) error
+	Close() error
+}
+
+// UploadError is passed to Options.OnError if any of the requests
+// made to upload a bundle of view data failed. The other requests
+// of the bundle are uploaded regardless.
+type UploadError struct {
+	// Errors contains the final error of each failed request.
+	Errors []error
+
+	// DroppedPoints is the number of points that could not be uploaded.
+	DroppedPoints int
+}
+
+func (e *UploadError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d points dropped: %s", e.DroppedPoints, strings.Join(msgs, "; "))
 }
 
 // Options contains options for configuring the exporter.
@@ -69,6 +112,7 @@ type Options struct {
 
 	// OnError is the hook to be called when there is
 	// an error occured when uploading the stats data.
+	// Upload failures are reported as an *UploadError.
 	// If no custom hook is set, errors are logged.
 	// Optional.
 	OnError func(err error)
@@ -97,10 +141,15 @@ func NewExporter(o Options) (*Exporter, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newExporter(o, client), nil
+}
+
+func newExporter(o Options, client metricClient) *Exporter {
 	e := &Exporter{
 		c:            client,
 		o:            o,
 		createdViews: make(map[string]*metricpb.MetricDescriptor),
+		backoff:      retry.DefaultBackoff,
 	}
 	e.bundler = bundler.NewBundler((*stats.ViewData)(nil), func(bundle interface{}) {
 		vds := bundle.([]*stats.ViewData)
@@ -110,7 +159,7 @@ func NewExporter(o Options) (*Exporter, error) {
 	})
 	e.bundler.DelayThreshold = e.o.BundleDelayThreshold
 	e.bundler.BundleCountThreshold = e.o.BundleCountThreshold
-	return e, nil
+	return e
 }
 
 // Export exports to the Stackdriver Monitoring if view data
@@ -130,6 +179,14 @@ func (e *Exporter) Flush() {
 	e.bundler.Flush()
 }
 
+// Close flushes the exporter and closes the connection
+// to Stackdriver Monitoring. The exporter cannot be used
+// after it is closed.
+func (e *Exporter) Close() error {
+	e.Flush()
+	return e.c.Close()
+}
+
 func (e *Exporter) onError(err error) {
 	if e.o.OnError != nil {
 		e.o.OnError(err)
@@ -141,6 +198,9 @@ func (e *Exporter) onError(err error) {
 func (e *Exporter) upload(vds []*stats.ViewData) error {
 	ctx := context.Background()
 
+	var errs []error
+	var dropped int
+	created := make([]*stats.ViewData, 0, len(vds))
 	for _, vd := range vds {
 		if _, ok := vd.View.Window().(stats.Cumulative); !ok {
 			// TODO(jbd): Only Cumulative window will be exported to Stackdriver in this version.
@@ -148,18 +208,51 @@ func (e *Exporter) upload(vds []*stats.ViewData) error {
 			continue
 		}
 		if err := e.createMeasure(ctx, vd); err != nil {
-			return err
+			errs = append(errs, err)
+			dropped += len(vd.Rows)
+			continue
 		}
+		created = append(created, vd)
 	}
-	for _, req := range e.makeReq(vds, maxTimeSeriesPerUpload) {
-		if err := e.c.CreateTimeSeries(ctx, req); err != nil {
-			// TODO(jbd): Don't fail fast here, batch errors?
-			return err
-		}
+	for _, req := range e.makeReq(created, maxTimeSeriesPerUpload) {
+		dropped += e.createTimeSeries(ctx, req, &errs)
+	}
+	statsCtx := stats.ExporterContext(context.Background(), e)
+	if dropped > 0 {
+		stats.Record(statsCtx, DroppedPointCount.M(int64(dropped)))
+	}
+	if len(errs) > 0 {
+		stats.Record(statsCtx, stats.ExportErrorCount.M(int64(len(errs))))
+		return &UploadError{Errors: errs, DroppedPoints: dropped}
 	}
 	return nil
 }
 
+// createTimeSeries uploads req, retrying it on transient errors. If the
+// backend rejects the request as a whole, it is split in halves so that
+// the time series that are valid are still uploaded. The error of every
+// part that fails is appended to errs, and the number of dropped time
+// series is returned.
+func (e *Exporter) createTimeSeries(ctx context.Context, req *monitoringpb.CreateTimeSeriesRequest, errs *[]error) int {
+	err := retry.Do(ctx, e.backoff, func() error {
+		return e.c.CreateTimeSeries(ctx, req)
+	})
+	if err == nil {
+		return 0
+	}
+	if n := len(req.TimeSeries); n > 1 && retry.Splittable(err) {
+		return e.createTimeSeries(ctx, &monitoringpb.CreateTimeSeriesRequest{
+			Name:       req.Name,
+			TimeSeries: req.TimeSeries[:n/2],
+		}, errs) + e.createTimeSeries(ctx, &monitoringpb.CreateTimeSeriesRequest{
+			Name:       req.Name,
+			TimeSeries: req.TimeSeries[n/2:],
+		}, errs)
+	}
+	*errs = append(*errs, err)
+	return len(req.TimeSeries)
+}
+
 func (e *Exporter) makeReq(vds []*stats.ViewData, limit int) []*monitoringpb.CreateTimeSeriesRequest {
 	var reqs []*monitoringpb.CreateTimeSeriesRequest
 	var timeSeries []*monitoringpb.TimeSeries
@@ -222,13 +315,17 @@ func (e *Exporter) createMeasure(ctx context.Context, vd *stats.ViewData) error
 	}
 
 	metricName := monitoring.MetricMetricDescriptorPath(e.o.ProjectID, namespacedViewName(viewName, true))
-	md, err := e.c.GetMetricDescriptor(ctx, &monitoringpb.GetMetricDescriptorRequest{
-		Name: metricName,
-	})
-	if err := equalAggWindowTagKeys(md, agg, window, tagKeys); err != nil {
+	var md *metricpb.MetricDescriptor
+	err := retry.Do(ctx, e.backoff, func() (err error) {
+		md, err = e.c.GetMetricDescriptor(ctx, &monitoringpb.GetMetricDescriptorRequest{
+			Name: metricName,
+		})
 		return err
-	}
+	})
 	if err == nil {
+		if err := equalAggWindowTagKeys(md, agg, window, tagKeys); err != nil {
+			return err
+		}
 		e.createdViews[viewName] = md
 		return nil
 	}
@@ -257,7 +354,7 @@ func (e *Exporter) createMeasure(ctx context.Context, vd *stats.ViewData) error
 		return fmt.Errorf("unsupported window type: %T", window)
 	}
 
-	md, err = e.c.CreateMetricDescriptor(ctx, &monitoringpb.CreateMetricDescriptorRequest{
+	req := &monitoringpb.CreateMetricDescriptorRequest{
 		Name: monitoring.MetricProjectPath(e.o.ProjectID),
 		MetricDescriptor: &metricpb.MetricDescriptor{
 			DisplayName: viewName,
@@ -268,6 +365,10 @@ func (e *Exporter) createMeasure(ctx context.Context, vd *stats.ViewData) error
 			ValueType:   valueType,
 			Labels:      newLabelDescriptors(vd.View.TagKeys()),
 		},
+	}
+	err = retry.Do(ctx, e.backoff, func() (err error) {
+		md, err = e.c.CreateMetricDescriptor(ctx, req)
+		return err
 	})
 	if err != nil {
 		return err
@@ -15,20 +15,26 @@
 package stackdriver
 
 import (
+	"context"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"google.golang.org/genproto/googleapis/api/label"
 
+	"go.opencensus.io/internal/retry"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	gax "github.com/googleapis/gax-go"
 	metricpb "google.golang.org/genproto/googleapis/api/metric"
 	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
 	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestExporter_makeReq(t *testing.T) {
@@ -229,6 +235,91 @@ func TestExporter_makeReq_batching(t *testing.T) {
 	}
 }
 
+func TestExporter_upload_retry(t *testing.T) {
+	view := newTestView(t, "retryview")
+	defer stats.DeleteMeasure(view.Measure())
+	defer view.Unregister()
+
+	c := &fakeMetricClient{
+		createTimeSeries: func(calls int, req *monitoringpb.CreateTimeSeriesRequest) error {
+			if calls < 3 {
+				return grpc.Errorf(codes.Unavailable, "unavailable")
+			}
+			return nil
+		},
+	}
+	e := newTestExporter(c)
+	if err := e.upload([]*stats.ViewData{newTestCumViewData(view, time.Now(), time.Now())}); err != nil {
+		t.Fatalf("upload() = %v; want no error", err)
+	}
+	if got, want := len(c.timeSeriesReqs), 3; got != want {
+		t.Errorf("CreateTimeSeries called %d times; want %d", got, want)
+	}
+}
+
+func TestExporter_upload_partialFailure(t *testing.T) {
+	view := newTestView(t, "partialview")
+	defer stats.DeleteMeasure(view.Measure())
+	defer view.Unregister()
+
+	c := &fakeMetricClient{
+		createTimeSeries: func(calls int, req *monitoringpb.CreateTimeSeriesRequest) error {
+			for _, ts := range req.TimeSeries {
+				if ts.Metric.Labels["test_key"] == "test-value-2" {
+					return grpc.Errorf(codes.InvalidArgument, "invalid point")
+				}
+			}
+			return nil
+		},
+	}
+	e := newTestExporter(c)
+	err := e.upload([]*stats.ViewData{newTestCumViewData(view, time.Now(), time.Now())})
+	uerr, ok := err.(*UploadError)
+	if !ok {
+		t.Fatalf("upload() = %v; want an *UploadError", err)
+	}
+	if got, want := uerr.DroppedPoints, 1; got != want {
+		t.Errorf("DroppedPoints = %d; want %d", got, want)
+	}
+	if got, want := len(uerr.Errors), 1; got != want {
+		t.Errorf("len(Errors) = %d; want %d", got, want)
+	}
+
+	var uploaded int
+	for _, req := range c.timeSeriesReqs {
+		if len(req.TimeSeries) == 1 && req.TimeSeries[0].Metric.Labels["test_key"] == "test-value-1" {
+			uploaded++
+		}
+	}
+	if uploaded != 1 {
+		t.Errorf("valid time series was not uploaded separately; requests = %v", c.timeSeriesReqs)
+	}
+}
+
+func TestExporter_upload_nonRetryable(t *testing.T) {
+	view := newTestView(t, "permissionview")
+	defer stats.DeleteMeasure(view.Measure())
+	defer view.Unregister()
+
+	c := &fakeMetricClient{
+		createTimeSeries: func(calls int, req *monitoringpb.CreateTimeSeriesRequest) error {
+			return grpc.Errorf(codes.PermissionDenied, "denied")
+		},
+	}
+	e := newTestExporter(c)
+	err := e.upload([]*stats.ViewData{newTestCumViewData(view, time.Now(), time.Now())})
+	uerr, ok := err.(*UploadError)
+	if !ok {
+		t.Fatalf("upload() = %v; want an *UploadError", err)
+	}
+	if got, want := uerr.DroppedPoints, 2; got != want {
+		t.Errorf("DroppedPoints = %d; want %d", got, want)
+	}
+	if got, want := len(c.timeSeriesReqs), 1; got != want {
+		t.Errorf("CreateTimeSeries called %d times; want %d", got, want)
+	}
+}
+
 func TestEqualAggWindowTagKeys(t *testing.T) {
 	key1, _ := tag.NewKey("test-key-one")
 	key2, _ := tag.NewKey("test-key-two")
@@ -371,3 +462,58 @@ func newTestDistViewData(v *stats.View, start, end time.Time) *stats.ViewData {
 		End:   end,
 	}
 }
+
+func newTestView(t *testing.T, name string) *stats.View {
+	m, err := stats.NewMeasureInt64(name+"-measure", "measure desc", "unit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := tag.NewKey("test_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := stats.NewView(name, "desc", []tag.Key{key}, m, stats.CountAggregation{}, stats.Cumulative{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stats.RegisterView(v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func newTestExporter(c metricClient) *Exporter {
+	e := newExporter(Options{ProjectID: "proj-id"}, c)
+	e.backoff = retry.Backoff{
+		Initial:    time.Millisecond,
+		Max:        time.Millisecond,
+		Multiplier: 2,
+		Attempts:   5,
+	}
+	return e
+}
+
+type fakeMetricClient struct {
+	mu               sync.Mutex
+	timeSeriesReqs   []*monitoringpb.CreateTimeSeriesRequest
+	createTimeSeries func(calls int, req *monitoringpb.CreateTimeSeriesRequest) error
+}
+
+func (c *fakeMetricClient) GetMetricDescriptor(ctx context.Context, req *monitoringpb.GetMetricDescriptorRequest, opts ...gax.CallOption) (*metricpb.MetricDescriptor, error) {
+	return nil, grpc.Errorf(codes.NotFound, "not found")
+}
+
+func (c *fakeMetricClient) CreateMetricDescriptor(ctx context.Context, req *monitoringpb.CreateMetricDescriptorRequest, opts ...gax.CallOption) (*metricpb.MetricDescriptor, error) {
+	return req.MetricDescriptor, nil
+}
+
+func (c *fakeMetricClient) CreateTimeSeries(ctx context.Context, req *monitoringpb.CreateTimeSeriesRequest, opts ...gax.CallOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeSeriesReqs = append(c.timeSeriesReqs, req)
+	return c.createTimeSeries(len(c.timeSeriesReqs), req)
+}
+
+func (c *fakeMetricClient) Close() error {
+	return nil
+}
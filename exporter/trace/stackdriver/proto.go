@@ -15,9 +15,11 @@
 package stackdriver
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"runtime"
+	"strconv"
 	"time"
 	"unicode/utf8"
 
@@ -161,6 +163,11 @@ func timestampProto(t time.Time) *timestamppb.Timestamp {
 
 // copyAttributes copies a map of attributes to a proto map field.
 // It creates the map if it is nil.
+//
+// Stackdriver only supports bool, int and string attribute values, so
+// float64 values are converted to strings and arrays to JSON strings.
+// Attributes that can't be converted are dropped and counted in
+// DroppedAttributesCount.
 func copyAttributes(out **tracepb.Span_Attributes, in map[string]interface{}) {
 	if len(in) == 0 {
 		return
@@ -181,7 +188,18 @@ func copyAttributes(out **tracepb.Span_Attributes, in map[string]interface{}) {
 			av.Value = &tracepb.AttributeValue_IntValue{IntValue: value}
 		case string:
 			av.Value = &tracepb.AttributeValue_StringValue{StringValue: trunc(value, 256)}
+		case float64:
+			av.Value = &tracepb.AttributeValue_StringValue{StringValue: trunc(strconv.FormatFloat(value, 'g', -1, 64), 256)}
+		case []bool, []int64, []float64, []string:
+			b, err := json.Marshal(value)
+			if err != nil {
+				// Arrays with NaN or infinite values can't be encoded.
+				dropped++
+				continue
+			}
+			av.Value = &tracepb.AttributeValue_StringValue{StringValue: trunc(string(b), 256)}
 		default:
+			dropped++
 			continue
 		}
 		if len(key) > 128 {
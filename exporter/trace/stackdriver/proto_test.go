@@ -17,6 +17,7 @@ package stackdriver
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"sort"
@@ -104,6 +105,7 @@ func TestExportTrace(t *testing.T) {
 		trace.EndSpan(ctx1)
 	}
 	trace.EndSpan(ctx)
+	trace.Flush()
 	if len(te.spans) != 5 {
 		t.Errorf("got %d exported spans, want 5", len(te.spans))
 	}
@@ -339,6 +341,32 @@ func TestEnums(t *testing.T) {
 	}
 }
 
+func TestCopyAttributes(t *testing.T) {
+	var got *tracepb.Span_Attributes
+	copyAttributes(&got, map[string]interface{}{
+		"float":   1.5,
+		"bools":   []bool{true, false},
+		"ints":    []int64{1, 2},
+		"floats":  []float64{0.5},
+		"strings": []string{"a", "b"},
+		"nan":     []float64{math.NaN()},
+		"int":     int(1),
+	})
+	want := &tracepb.Span_Attributes{
+		AttributeMap: map[string]*tracepb.AttributeValue{
+			"float":   &tracepb.AttributeValue{Value: &tracepb.AttributeValue_StringValue{StringValue: trunc("1.5", 256)}},
+			"bools":   &tracepb.AttributeValue{Value: &tracepb.AttributeValue_StringValue{StringValue: trunc("[true,false]", 256)}},
+			"ints":    &tracepb.AttributeValue{Value: &tracepb.AttributeValue_StringValue{StringValue: trunc("[1,2]", 256)}},
+			"floats":  &tracepb.AttributeValue{Value: &tracepb.AttributeValue_StringValue{StringValue: trunc("[0.5]", 256)}},
+			"strings": &tracepb.AttributeValue{Value: &tracepb.AttributeValue_StringValue{StringValue: trunc(`["a","b"]`, 256)}},
+		},
+		DroppedAttributesCount: 2,
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("copyAttributes: got %v want %v", got, want)
+	}
+}
+
 func BenchmarkProto(b *testing.B) {
 	sd := &trace.SpanData{
 		SpanContext: trace.SpanContext{
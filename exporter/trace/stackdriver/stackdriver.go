@@ -32,12 +32,16 @@ package stackdriver
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opencensus.io/internal"
+	"go.opencensus.io/internal/retry"
+	"go.opencensus.io/stats"
 
 	tracingclient "cloud.google.com/go/trace/apiv2"
+	gax "github.com/googleapis/gax-go"
 	"go.opencensus.io/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/api/option"
@@ -45,15 +49,55 @@ import (
 	tracepb "google.golang.org/genproto/googleapis/devtools/cloudtrace/v2"
 )
 
+// DroppedSpanCount is the number of spans the exporter failed to upload
+// to Stackdriver Trace, either because its buffer was full or because
+// the upload failed after retries.
+var DroppedSpanCount *stats.MeasureInt64
+
+func init() {
+	var err error
+	if DroppedSpanCount, err = stats.NewMeasureInt64("/opencensus.io/exporter/stackdriver/dropped_spans", "Spans dropped by the Stackdriver trace exporter", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/exporter/stackdriver/dropped_spans: %v", err)
+	}
+}
+
 // Exporter is an implementation of trace.Exporter that uploads spans to
 // Stackdriver.
 type Exporter struct {
 	projectID string
+	onError   func(err error)
 	bundler   *bundler.Bundler
 	// uploadFn defaults to uploadToStackdriver; it can be replaced for tests.
 	uploadFn func(spans []*trace.SpanData)
 	overflowLogger
-	client *tracingclient.Client
+	client  traceClient
+	backoff retry.Backoff
+}
+
+// traceClient is the subset of the Stackdriver Trace client
+// used by the exporter; it can be replaced for tests.
+type traceClient interface {
+	BatchWriteSpans(ctx context.Context, req *tracepb.BatchWriteSpansRequest, opts ...gax.CallOption) error
+	Close() error
+}
+
+// UploadError is passed to Options.OnError if any of the requests
+// made to upload a bundle of spans failed. The other requests
+// of the bundle are uploaded regardless.
+type UploadError struct {
+	// Errors contains the final error of each failed request.
+	Errors []error
+
+	// DroppedSpans is the number of spans that could not be uploaded.
+	DroppedSpans int
+}
+
+func (e *UploadError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d spans dropped: %s", e.DroppedSpans, strings.Join(msgs, "; "))
 }
 
 var _ trace.Exporter = (*Exporter)(nil)
@@ -63,6 +107,10 @@ var _ trace.Exporter = (*Exporter)(nil)
 // Only ProjectID is required.
 type Options struct {
 	ProjectID string
+	// OnError is the hook to be called when uploading spans fails.
+	// Upload failures are reported as an *UploadError.
+	// If no custom hook is set, errors are logged.
+	OnError func(err error)
 	// ClientOptions contains options used to configure the Stackdriver client.
 	ClientOptions []option.ClientOption
 	// BundleDelayThreshold is maximum length of time to wait before uploading a
@@ -88,10 +136,12 @@ func NewExporter(o Options) (*Exporter, error) {
 	return newExporter(o, client), nil
 }
 
-func newExporter(o Options, client *tracingclient.Client) *Exporter {
+func newExporter(o Options, client traceClient) *Exporter {
 	e := &Exporter{
 		projectID: o.ProjectID,
+		onError:   o.OnError,
 		client:    client,
+		backoff:   retry.DefaultBackoff,
 	}
 	bundler := bundler.NewBundler((*trace.SpanData)(nil), func(bundle interface{}) {
 		e.uploadFn(bundle.([]*trace.SpanData))
@@ -132,8 +182,10 @@ func (e *Exporter) Export(s *trace.SpanData) {
 		go e.uploadFn([]*trace.SpanData{s})
 	case bundler.ErrOverflow:
 		e.overflowLogger.log()
+		e.recordDropped(1)
 	default:
 		log.Println("OpenCensus Stackdriver exporter: failed to upload span:", err)
+		e.recordDropped(1)
 	}
 }
 
@@ -145,19 +197,70 @@ func (e *Exporter) Flush() {
 	e.bundler.Flush()
 }
 
+// Close flushes the exporter and closes the connection to Stackdriver.
+// The exporter cannot be used after it is closed.
+func (e *Exporter) Close() error {
+	e.Flush()
+	return e.client.Close()
+}
+
 // uploadToStackdriver uploads a set of spans to Stackdriver.
 func (e *Exporter) uploadToStackdriver(spans []*trace.SpanData) {
-	req := tracepb.BatchWriteSpansRequest{
+	ctx := context.Background()
+	req := &tracepb.BatchWriteSpansRequest{
 		Name:  "projects/" + e.projectID,
 		Spans: make([]*tracepb.Span, 0, len(spans)),
 	}
 	for _, span := range spans {
 		req.Spans = append(req.Spans, protoFromSpanData(span, e.projectID))
 	}
-	err := e.client.BatchWriteSpans(context.Background(), &req)
-	if err != nil {
-		log.Printf("OpenCensus Stackdriver exporter: failed to upload %d spans: %v", len(spans), err)
+	var errs []error
+	dropped := e.batchWriteSpans(ctx, req, &errs)
+	if dropped > 0 {
+		e.recordDropped(dropped)
+	}
+	if len(errs) == 0 {
+		return
+	}
+	stats.Record(stats.ExporterContext(context.Background(), e), stats.ExportErrorCount.M(int64(len(errs))))
+	err := &UploadError{Errors: errs, DroppedSpans: dropped}
+	if e.onError != nil {
+		e.onError(err)
+		return
+	}
+	log.Printf("OpenCensus Stackdriver exporter: failed to upload %d spans: %v", len(spans), err)
+}
+
+// recordDropped records that n spans were dropped by the exporter, both
+// as DroppedSpanCount and as the trace.ExportDroppedCount of the exporter.
+func (e *Exporter) recordDropped(n int) {
+	ctx := stats.ExporterContext(context.Background(), e)
+	stats.Record(ctx, DroppedSpanCount.M(int64(n)), trace.ExportDroppedCount.M(int64(n)))
+}
+
+// batchWriteSpans uploads req, retrying it on transient errors. If the
+// backend rejects the request as a whole, it is split in halves so that
+// the spans that are valid are still uploaded. The error of every part
+// that fails is appended to errs, and the number of dropped spans is
+// returned.
+func (e *Exporter) batchWriteSpans(ctx context.Context, req *tracepb.BatchWriteSpansRequest, errs *[]error) int {
+	err := retry.Do(ctx, e.backoff, func() error {
+		return e.client.BatchWriteSpans(ctx, req)
+	})
+	if err == nil {
+		return 0
+	}
+	if n := len(req.Spans); n > 1 && retry.Splittable(err) {
+		return e.batchWriteSpans(ctx, &tracepb.BatchWriteSpansRequest{
+			Name:  req.Name,
+			Spans: req.Spans[:n/2],
+		}, errs) + e.batchWriteSpans(ctx, &tracepb.BatchWriteSpansRequest{
+			Name:  req.Name,
+			Spans: req.Spans[n/2:],
+		}, errs)
 	}
+	*errs = append(*errs, err)
+	return len(req.Spans)
 }
 
 // overflowLogger ensures that at most one overflow error log message is
@@ -16,10 +16,16 @@ package stackdriver
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	gax "github.com/googleapis/gax-go"
+	"go.opencensus.io/internal/retry"
 	"go.opencensus.io/trace"
+	tracepb "google.golang.org/genproto/googleapis/devtools/cloudtrace/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestBundling(t *testing.T) {
@@ -60,3 +66,71 @@ func TestBundling(t *testing.T) {
 	case <-time.After(time.Second / 5):
 	}
 }
+
+func TestUploadRetryAndSplit(t *testing.T) {
+	c := &fakeTraceClient{
+		batchWriteSpans: func(calls int, req *tracepb.BatchWriteSpansRequest) error {
+			if calls == 1 {
+				return grpc.Errorf(codes.Unavailable, "unavailable")
+			}
+			for _, s := range req.Spans {
+				if s.DisplayName.Value == "bad" {
+					return grpc.Errorf(codes.InvalidArgument, "invalid span")
+				}
+			}
+			return nil
+		},
+	}
+	var errs []error
+	e := newExporter(Options{
+		ProjectID: "fakeProjectID",
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	}, c)
+	e.backoff = retry.Backoff{
+		Initial:    time.Millisecond,
+		Max:        time.Millisecond,
+		Multiplier: 2,
+		Attempts:   3,
+	}
+
+	e.uploadToStackdriver([]*trace.SpanData{
+		{Name: "good1"},
+		{Name: "good2"},
+		{Name: "bad"},
+	})
+
+	// The first attempt is retried, the bundle is rejected and split in
+	// [good1] and [good2 bad], and the latter is split again.
+	if got, want := len(c.reqs), 6; got != want {
+		t.Errorf("BatchWriteSpans called %d times; want %d", got, want)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("OnError called %d times; want once", len(errs))
+	}
+	uerr, ok := errs[0].(*UploadError)
+	if !ok {
+		t.Fatalf("OnError got %v; want an *UploadError", errs[0])
+	}
+	if got, want := uerr.DroppedSpans, 1; got != want {
+		t.Errorf("DroppedSpans = %d; want %d", got, want)
+	}
+}
+
+type fakeTraceClient struct {
+	mu              sync.Mutex
+	reqs            []*tracepb.BatchWriteSpansRequest
+	batchWriteSpans func(calls int, req *tracepb.BatchWriteSpansRequest) error
+}
+
+func (c *fakeTraceClient) BatchWriteSpans(ctx context.Context, req *tracepb.BatchWriteSpansRequest, opts ...gax.CallOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reqs = append(c.reqs, req)
+	return c.batchWriteSpans(len(c.reqs), req)
+}
+
+func (c *fakeTraceClient) Close() error {
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue contains the bounded queues used internally
+// to hand data to exporters asynchronously.
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// DropPolicy determines what happens when an item is added to a full queue.
+type DropPolicy int
+
+const (
+	// DropNewest drops the item being added.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest item in the queue to make room.
+	DropOldest
+	// Block blocks until there is room in the queue.
+	Block
+)
+
+// Queue is a bounded queue whose items are handled
+// one at a time by a dedicated goroutine.
+type Queue struct {
+	c      chan interface{}
+	policy DropPolicy
+	handle func(item interface{})
+
+	mu      sync.Mutex
+	cond    *sync.Cond // signaled when pending drops to zero
+	pending int        // items added but not yet handled or dropped
+	closed  bool
+	done    chan struct{}
+}
+
+// New returns a queue of the given size and starts the goroutine
+// calling handle for each item added to it.
+func New(size int, policy DropPolicy, handle func(item interface{})) *Queue {
+	if size <= 0 {
+		size = 1
+	}
+	q := &Queue{
+		c:      make(chan interface{}, size),
+		policy: policy,
+		handle: handle,
+		done:   make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+func (q *Queue) run() {
+	for {
+		select {
+		case item := <-q.c:
+			q.handle(item)
+			q.release(1)
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// Add adds item to the queue and returns the number of items that were
+// dropped as a result, either item itself or an older one.
+func (q *Queue) Add(item interface{}) (dropped int) {
+	return q.add(item, time.Time{})
+}
+
+// AddBefore is like Add, except that with the Block policy it waits for
+// room in the queue only until deadline, after which item is dropped.
+func (q *Queue) AddBefore(item interface{}, deadline time.Time) (dropped int) {
+	return q.add(item, deadline)
+}
+
+func (q *Queue) add(item interface{}, deadline time.Time) (dropped int) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return 1
+	}
+	q.pending++
+	q.mu.Unlock()
+
+	switch q.policy {
+	case DropOldest:
+		for {
+			select {
+			case q.c <- item:
+				return dropped
+			default:
+			}
+			select {
+			case <-q.c:
+				dropped++
+				q.release(1)
+			default:
+			}
+		}
+	case Block:
+		if deadline.IsZero() {
+			q.c <- item
+			return 0
+		}
+		select {
+		case q.c <- item:
+			return 0
+		default:
+		}
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		select {
+		case q.c <- item:
+			return 0
+		case <-t.C:
+			q.release(1)
+			return 1
+		}
+	default:
+		select {
+		case q.c <- item:
+			return 0
+		default:
+			q.release(1)
+			return 1
+		}
+	}
+}
+
+// Len returns the number of items waiting in the queue.
+func (q *Queue) Len() int {
+	return len(q.c)
+}
+
+// Flush waits until all the items added to the queue are handled.
+func (q *Queue) Flush() {
+	q.mu.Lock()
+	for q.pending > 0 {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// Close flushes the queue and stops its goroutine.
+// Items added after Close are dropped.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	for q.pending > 0 {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+	close(q.done)
+}
+
+func (q *Queue) release(n int) {
+	q.mu.Lock()
+	q.pending -= n
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+	q.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockedQueue returns a queue of size 2 whose goroutine is blocked
+// handling the item 0 until unblock is called.
+func blockedQueue(policy DropPolicy) (q *Queue, handled func() []int, unblock func()) {
+	var mu sync.Mutex
+	var items []int
+	started := make(chan bool)
+	release := make(chan bool)
+	q = New(2, policy, func(item interface{}) {
+		if item.(int) == 0 {
+			started <- true
+			<-release
+		}
+		mu.Lock()
+		items = append(items, item.(int))
+		mu.Unlock()
+	})
+	q.Add(0)
+	<-started
+	handled = func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), items...)
+	}
+	return q, handled, func() { close(release) }
+}
+
+func TestDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy      DropPolicy
+		wantDropped []int
+		wantHandled []int
+	}{
+		{DropNewest, []int{0, 0, 1}, []int{0, 1, 2}},
+		{DropOldest, []int{0, 0, 1}, []int{0, 2, 3}},
+	}
+	for _, tt := range tests {
+		q, handled, unblock := blockedQueue(tt.policy)
+		var dropped []int
+		for i := 1; i <= 3; i++ {
+			dropped = append(dropped, q.Add(i))
+		}
+		if got, want := q.Len(), 2; got != want {
+			t.Errorf("policy %d: Len() = %d; want %d", tt.policy, got, want)
+		}
+		unblock()
+		q.Flush()
+		if !reflect.DeepEqual(dropped, tt.wantDropped) {
+			t.Errorf("policy %d: Add() dropped %v; want %v", tt.policy, dropped, tt.wantDropped)
+		}
+		if got := handled(); !reflect.DeepEqual(got, tt.wantHandled) {
+			t.Errorf("policy %d: handled %v; want %v", tt.policy, got, tt.wantHandled)
+		}
+		q.Close()
+	}
+}
+
+func TestBlock(t *testing.T) {
+	q, handled, unblock := blockedQueue(Block)
+	q.Add(1)
+	q.Add(2)
+
+	added := make(chan int)
+	go func() { added <- q.Add(3) }()
+	select {
+	case <-added:
+		t.Fatal("Add() returned while the queue was full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	unblock()
+	if n := <-added; n != 0 {
+		t.Errorf("Add() dropped %d items; want 0", n)
+	}
+	q.Close()
+	if got, want := handled(), []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %v; want %v", got, want)
+	}
+}
+
+func TestAddBefore(t *testing.T) {
+	q, handled, unblock := blockedQueue(Block)
+	q.Add(1)
+	q.Add(2)
+	if n := q.AddBefore(3, time.Now().Add(10*time.Millisecond)); n != 1 {
+		t.Errorf("AddBefore() dropped %d items; want 1", n)
+	}
+	unblock()
+	q.Close()
+	if got, want := handled(), []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %v; want %v", got, want)
+	}
+}
+
+func TestClose(t *testing.T) {
+	var n int
+	q := New(10, DropNewest, func(item interface{}) { n++ })
+	for i := 0; i < 5; i++ {
+		q.Add(i)
+	}
+	q.Close()
+	if n != 5 {
+		t.Errorf("%d items handled before Close returned; want 5", n)
+	}
+	if dropped := q.Add(5); dropped != 1 {
+		t.Errorf("Add() after Close dropped %d items; want 1", dropped)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry contains the retry policy used
+// internally by the exporters when calling backends.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Backoff configures an exponential backoff with jitter.
+type Backoff struct {
+	// Initial is the upper bound of the first delay.
+	Initial time.Duration
+	// Max is the upper bound of any delay.
+	Max time.Duration
+	// Multiplier is the factor the delay grows by after each attempt.
+	Multiplier float64
+	// Attempts is the maximum number of calls, including the first one.
+	Attempts int
+}
+
+// DefaultBackoff is the backoff used by the exporters unless
+// they are configured otherwise.
+var DefaultBackoff = Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 2,
+	Attempts:   5,
+}
+
+var (
+	mu  sync.Mutex // guards rng
+	rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Do calls fn until it succeeds, returns an error that is not retryable,
+// the attempts are exhausted or ctx is done. It returns the last error
+// returned by fn.
+func Do(ctx context.Context, b Backoff, fn func() error) error {
+	delay := b.Initial
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !Retryable(err) || attempt >= b.Attempts {
+			return err
+		}
+		t := time.NewTimer(jitter(delay))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay = time.Duration(float64(delay) * b.Multiplier)
+		if delay > b.Max {
+			delay = b.Max
+		}
+	}
+}
+
+// Retryable reports whether err is a transient gRPC error
+// after which the same request may succeed.
+func Retryable(err error) bool {
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// Splittable reports whether err indicates that the request was too large
+// or contained invalid items, in which case sending the items in smaller
+// requests lets the valid ones through.
+func Splittable(err error) bool {
+	switch grpc.Code(err) {
+	case codes.InvalidArgument, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// jitter returns a random duration in [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := int64(d / 2)
+	mu.Lock()
+	n := rng.Int63n(half)
+	mu.Unlock()
+	return time.Duration(half + n)
+}
@@ -0,0 +1,96 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestDo(t *testing.T) {
+	b := Backoff{
+		Initial:    time.Millisecond,
+		Max:        2 * time.Millisecond,
+		Multiplier: 2,
+		Attempts:   3,
+	}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "success",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "retried until success",
+			errs:      []error{grpc.Errorf(codes.Unavailable, ""), grpc.Errorf(codes.DeadlineExceeded, ""), nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{grpc.Errorf(codes.Unavailable, ""), grpc.Errorf(codes.Unavailable, ""), grpc.Errorf(codes.Unavailable, ""), nil},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "not retryable",
+			errs:      []error{grpc.Errorf(codes.PermissionDenied, ""), nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "not a grpc error",
+			errs:      []error{errors.New("failed"), nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		var calls int
+		err := Do(context.Background(), b, func() error {
+			err := tt.errs[calls]
+			calls++
+			return err
+		})
+		if calls != tt.wantCalls {
+			t.Errorf("%s: fn called %d times; want %d", tt.name, calls, tt.wantCalls)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Do() = %v; want error = %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDo_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int
+	err := Do(ctx, Backoff{Initial: time.Hour, Max: time.Hour, Multiplier: 2, Attempts: 3}, func() error {
+		calls++
+		return grpc.Errorf(codes.Unavailable, "")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() = %v after %d calls; want the first error", err, calls)
+	}
+}
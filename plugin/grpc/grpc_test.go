@@ -58,6 +58,7 @@ func TestNewClientStatsHandler(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	trace.Flush()
 	traces := te.buffer
 
 	if got, want := len(stats), 1; got != want {
@@ -102,6 +103,7 @@ func TestNewServerStatsHandler(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	trace.Flush()
 	traces := te.buffer
 
 	if got, want := len(stats), 1; got != want {
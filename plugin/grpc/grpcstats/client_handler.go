@@ -24,13 +24,32 @@ import (
 	"go.opencensus.io/tag"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/stats"
 )
 
 // ClientStatsHandler is a stats.Handler implementation
 // that collects stats for a gRPC client. Predefined
 // measures and views can be used to access the collected data.
-type ClientStatsHandler struct{}
+type ClientStatsHandler struct {
+	o ClientOptions
+}
+
+// ClientOptions contains options for a ClientStatsHandler.
+type ClientOptions struct {
+	// MetadataTags maps outgoing metadata keys to the tag keys their
+	// values are recorded under. Only the first value of a metadata key
+	// is used.
+	MetadataTags map[string]tag.Key
+
+	// DisableTagPropagation stops the tags in the context from being
+	// sent to the server.
+	DisableTagPropagation bool
+
+	// Views are subscribed to when the handler is created.
+	// DefaultClientViews lists the predefined client views.
+	Views []*istats.View
+}
 
 var _ stats.Handler = &ClientStatsHandler{}
 
@@ -41,19 +60,28 @@ func NewClientStatsHandler() *ClientStatsHandler {
 	return &ClientStatsHandler{}
 }
 
+// NewClientStatsHandlerWithOptions is like NewClientStatsHandler but
+// configures the handler with o. It returns an error if any of o.Views
+// cannot be subscribed to.
+func NewClientStatsHandlerWithOptions(o ClientOptions) (*ClientStatsHandler, error) {
+	if err := subscribeViews(o.Views); err != nil {
+		return nil, err
+	}
+	return &ClientStatsHandler{o: o}, nil
+}
+
 // TODO(jbd): Remove NewClientStatsHandler and NewServerStatsHandler
 // given they are not doing anything than returning a zero value pointer.
 
 // TagConn adds connection related data to the given context and returns the
 // new context.
 func (h *ClientStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
-	// Do nothing. This is here to satisfy the interface "google.golang.org/grpc/stats.Handler"
-	return ctx
+	return tagConn(ctx, grpcClientConnKey, "client", info)
 }
 
 // HandleConn processes the connection events.
 func (h *ClientStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
-	// Do nothing. This is here to satisfy the interface "google.golang.org/grpc/stats.Handler"
+	handleConn(ctx, grpcClientConnKey, s)
 }
 
 // TagRPC gets the tag.Map populated by the application code, serializes
@@ -80,14 +108,19 @@ func (h *ClientStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo)
 		startTime: startTime,
 	}
 
-	ts := tag.FromContext(ctx)
-	encoded := tag.Encode(ts)
-	ctx = stats.SetTags(ctx, encoded)
+	if !h.o.DisableTagPropagation {
+		ts := tag.FromContext(ctx)
+		encoded := tag.Encode(ts)
+		ctx = metadata.AppendToOutgoingContext(ctx, tagsBinHeader, string(encoded))
+	}
 
-	tagMap, err := tag.NewMap(ctx,
+	mods := []tag.Mutator{
 		tag.Upsert(keyService, serviceName),
 		tag.Upsert(keyMethod, methodName),
-	)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	mods = append(mods, metadataMutators(md, h.o.MetadataTags)...)
+	tagMap, err := tag.NewMap(ctx, mods...)
 	if err == nil {
 		ctx = tag.NewContext(ctx, tagMap)
 	}
@@ -101,7 +134,9 @@ func (h *ClientStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo)
 // HandleRPC processes the RPC events.
 func (h *ClientStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
 	switch st := s.(type) {
-	case *stats.Begin, *stats.OutHeader, *stats.InHeader, *stats.InTrailer, *stats.OutTrailer:
+	case *stats.Begin:
+		h.handleRPCBegin(ctx, st)
+	case *stats.OutHeader, *stats.InHeader, *stats.InTrailer, *stats.OutTrailer:
 		// do nothing for client
 	case *stats.OutPayload:
 		h.handleRPCOutPayload(ctx, st)
@@ -114,6 +149,17 @@ func (h *ClientStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
 	}
 }
 
+func (h *ClientStatsHandler) handleRPCBegin(ctx context.Context, s *stats.Begin) {
+	d, ok := ctx.Value(grpcClientRPCKey).(*rpcData)
+	if !ok {
+		if grpclog.V(2) {
+			grpclog.Infoln("clientHandler.handleRPCBegin failed to retrieve *rpcData from context")
+		}
+		return
+	}
+	d.handleBegin(s)
+}
+
 func (h *ClientStatsHandler) handleRPCOutPayload(ctx context.Context, s *stats.OutPayload) {
 	d, ok := ctx.Value(grpcClientRPCKey).(*rpcData)
 	if !ok {
@@ -124,6 +170,7 @@ func (h *ClientStatsHandler) handleRPCOutPayload(ctx context.Context, s *stats.O
 	}
 
 	istats.Record(ctx, RPCClientRequestBytes.M(int64(s.Length)))
+	atomic.AddInt64(&d.reqBytes, int64(s.Length))
 	atomic.AddInt64(&d.reqCount, 1)
 }
 
@@ -136,7 +183,12 @@ func (h *ClientStatsHandler) handleRPCInPayload(ctx context.Context, s *stats.In
 		return
 	}
 
-	istats.Record(ctx, RPCClientResponseBytes.M(int64(s.Length)))
+	m := []istats.Measurement{RPCClientResponseBytes.M(int64(s.Length))}
+	if d.isFirstResponse() {
+		m = append(m, RPCClientTimeToFirstResponse.M(float64(time.Since(d.startTime))/float64(time.Millisecond)))
+	}
+	istats.Record(ctx, m...)
+	atomic.AddInt64(&d.respBytes, int64(s.Length))
 	atomic.AddInt64(&d.respCount, 1)
 }
 
@@ -159,16 +211,18 @@ func (h *ClientStatsHandler) handleRPCEnd(ctx context.Context, s *stats.End) {
 	m = append(m, RPCClientFinishedCount.M(1))
 	m = append(m, RPCClientRoundTripLatency.M(float64(elapsedTime)/float64(time.Millisecond)))
 
+	// The status tag is recorded for successful RPCs too so that error
+	// ratios can be computed from a single view.
+	newTagMap, err := tag.NewMap(ctx,
+		tag.Upsert(keyOpStatus, statusCodeName(s.Error)),
+	)
+	if err == nil {
+		ctx = tag.NewContext(ctx, newTagMap)
+	}
 	if s.Error != nil {
-		errorCode := s.Error.Error()
-		newTagMap, err := tag.NewMap(ctx,
-			tag.Upsert(keyOpStatus, errorCode),
-		)
-		if err == nil {
-			ctx = tag.NewContext(ctx, newTagMap)
-		}
 		m = append(m, RPCClientErrorCount.M(1))
 	}
+	m = append(m, d.streamMeasurements(RPCClientStreamRequestCount, RPCClientStreamResponseCount, RPCClientStreamRequestBytes, RPCClientStreamResponseBytes)...)
 
 	istats.Record(ctx, m...)
 }
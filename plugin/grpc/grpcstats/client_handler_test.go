@@ -16,7 +16,6 @@
 package grpcstats
 
 import (
-	"errors"
 	"testing"
 
 	"golang.org/x/net/context"
@@ -24,6 +23,9 @@ import (
 	istats "go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/stats"
 )
 
@@ -82,6 +84,19 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCClientResponseCountView },
 					[]*istats.Row{
@@ -94,6 +109,19 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCClientRequestBytesView },
 					[]*istats.Row{
@@ -147,7 +175,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{Length: 10},
 						{Length: 10},
 					},
-					&stats.End{Error: errors.New("someError")},
+					&stats.End{Error: grpc.Errorf(codes.Internal, "someError")},
 				},
 			},
 			[]*wantData{
@@ -157,7 +185,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -176,6 +204,27 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCClientResponseCountView },
 					[]*istats.Row{
@@ -188,6 +237,27 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 			},
 		},
 		{
@@ -216,7 +286,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{Length: 4096},
 						{Length: 16384},
 					},
-					&stats.End{Error: errors.New("someError1")},
+					&stats.End{Error: grpc.Errorf(codes.Unavailable, "someError1")},
 				},
 				{
 					[]tagPair{{k1, "v11"}, {k2, "v22"}},
@@ -230,7 +300,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{Length: 4096},
 						{Length: 16384},
 					},
-					&stats.End{Error: errors.New("someError2")},
+					&stats.End{Error: grpc.Errorf(codes.Internal, "someError2")},
 				},
 			},
 			[]*wantData{
@@ -240,7 +310,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError1"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -248,7 +318,7 @@ func TestClientDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError2"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -267,6 +337,35 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCClientResponseCountView },
 					[]*istats.Row{
@@ -279,6 +378,35 @@ func TestClientDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCClientResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCClientRequestBytesView },
 					[]*istats.Row{
@@ -309,7 +437,7 @@ func TestClientDefaultCollections(t *testing.T) {
 
 	for _, tc := range tcs {
 		// Register views.
-		for _, v := range clientViews {
+		for _, v := range DefaultClientViews {
 			if err := v.Subscribe(); err != nil {
 				t.Error(err)
 			}
@@ -364,7 +492,7 @@ func TestClientDefaultCollections(t *testing.T) {
 		}
 
 		// Unregister views to cleanup.
-		for _, v := range clientViews {
+		for _, v := range DefaultClientViews {
 			if err := v.Unsubscribe(); err != nil {
 				t.Error(err)
 			}
@@ -381,3 +509,41 @@ func containsRow(rows []*istats.Row, r *istats.Row) bool {
 	}
 	return false
 }
+
+func TestClientOptions(t *testing.T) {
+	k1, _ := tag.NewKey("k1")
+	user, _ := tag.NewKey("user")
+
+	ts, err := tag.NewMap(context.Background(), tag.Upsert(k1, "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := tag.NewContext(context.Background(), ts)
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user", "alice"))
+	info := &stats.RPCTagInfo{FullMethodName: "/package.service/method"}
+
+	tcs := []struct {
+		label     string
+		o         ClientOptions
+		wantUser  string
+		propagate bool
+	}{
+		{label: "default", propagate: true},
+		{label: "metadata", o: ClientOptions{MetadataTags: map[string]tag.Key{"x-user": user}}, wantUser: "alice", propagate: true},
+		{label: "no propagation", o: ClientOptions{DisableTagPropagation: true}},
+	}
+	for _, tc := range tcs {
+		h, err := NewClientStatsHandlerWithOptions(tc.o)
+		if err != nil {
+			t.Fatalf("%v: NewClientStatsHandlerWithOptions = %v", tc.label, err)
+		}
+		ctx := h.TagRPC(ctx, info)
+		if got, _ := tag.FromContext(ctx).Value(user); got != tc.wantUser {
+			t.Errorf("%v: user tag = %q; want %q", tc.label, got, tc.wantUser)
+		}
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if got := len(md["grpc-tags-bin"]) > 0; got != tc.propagate {
+			t.Errorf("%v: tags propagated = %v; want %v", tc.label, got, tc.propagate)
+		}
+	}
+}
@@ -60,6 +60,14 @@ var (
 	RPCClientFinishedCountHourView    *stats.View
 	RPCClientRequestCountHourView     *stats.View
 	RPCClientResponseCountHourView    *stats.View
+
+	// Predefined client views broken down by status code. They are
+	// separate from the views above so that the tag keys of those do not
+	// change for existing users.
+	RPCClientRoundTripLatencyByStatusView *stats.View
+	RPCClientRequestCountByStatusView     *stats.View
+	RPCClientResponseCountByStatusView    *stats.View
+	RPCClientFinishedCountByStatusView    *stats.View
 )
 
 // TODO(acetechnologist): This is temporary and will need to be replaced by a
@@ -98,51 +106,60 @@ func defaultClientMeasures() {
 
 func defaultClientViews() {
 	RPCClientErrorCountView, _ = stats.NewView("grpc.io/client/error_count/distribution_cumulative", "RPC Errors", []tag.Key{keyOpStatus, keyService, keyMethod}, RPCClientErrorCount, aggCount, windowCumulative)
-	clientViews = append(clientViews, RPCClientErrorCountView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientErrorCountView)
 	RPCClientRoundTripLatencyView, _ = stats.NewView("grpc.io/client/roundtrip_latency/distribution_cumulative", "Latency in msecs", []tag.Key{keyService, keyMethod}, RPCClientRoundTripLatency, aggDistMillis, windowCumulative)
-	clientViews = append(clientViews, RPCClientRoundTripLatencyView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRoundTripLatencyView)
 	RPCClientRequestBytesView, _ = stats.NewView("grpc.io/client/request_bytes/distribution_cumulative", "Request bytes", []tag.Key{keyService, keyMethod}, RPCClientRequestBytes, aggDistBytes, windowCumulative)
-	clientViews = append(clientViews, RPCClientRequestBytesView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestBytesView)
 	RPCClientResponseBytesView, _ = stats.NewView("grpc.io/client/response_bytes/distribution_cumulative", "Response bytes", []tag.Key{keyService, keyMethod}, RPCClientResponseBytes, aggDistBytes, windowCumulative)
-	clientViews = append(clientViews, RPCClientResponseBytesView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseBytesView)
 	RPCClientRequestCountView, _ = stats.NewView("grpc.io/client/request_count/distribution_cumulative", "Count of request messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientRequestCount, aggDistCounts, windowCumulative)
-	clientViews = append(clientViews, RPCClientRequestCountView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestCountView)
 	RPCClientResponseCountView, _ = stats.NewView("grpc.io/client/response_count/distribution_cumulative", "Count of response messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientResponseCount, aggDistCounts, windowCumulative)
-	clientViews = append(clientViews, RPCClientResponseCountView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseCountView)
 
 	RPCClientRoundTripLatencyMinuteView, _ = stats.NewView("grpc.io/client/roundtrip_latency/minute_interval", "Minute stats for latency in msecs", []tag.Key{keyService, keyMethod}, RPCClientRoundTripLatency, aggDistMillis, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientRoundTripLatencyMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRoundTripLatencyMinuteView)
 	RPCClientRequestBytesMinuteView, _ = stats.NewView("grpc.io/client/request_bytes/minute_interval", "Minute stats for request size in bytes", []tag.Key{keyService, keyMethod}, RPCClientRequestBytes, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientRequestBytesMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestBytesMinuteView)
 	RPCClientResponseBytesMinuteView, _ = stats.NewView("grpc.io/client/response_bytes/minute_interval", "Minute stats for response size in bytes", []tag.Key{keyService, keyMethod}, RPCClientResponseBytes, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientResponseBytesMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseBytesMinuteView)
 	RPCClientErrorCountMinuteView, _ = stats.NewView("grpc.io/client/error_count/minute_interval", "Minute stats for rpc errors", []tag.Key{keyService, keyMethod}, RPCClientErrorCount, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientErrorCountMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientErrorCountMinuteView)
 	RPCClientStartedCountMinuteView, _ = stats.NewView("grpc.io/client/started_count/minute_interval", "Minute stats on the number of client RPCs started", []tag.Key{keyService, keyMethod}, RPCClientStartedCount, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientStartedCountMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStartedCountMinuteView)
 	RPCClientFinishedCountMinuteView, _ = stats.NewView("grpc.io/client/finished_count/minute_interval", "Minute stats on the number of client RPCs finished", []tag.Key{keyService, keyMethod}, RPCClientFinishedCount, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientFinishedCountMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientFinishedCountMinuteView)
 	RPCClientRequestCountMinuteView, _ = stats.NewView("grpc.io/client/request_count/minute_interval", "Minute stats on the count of request messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientRequestCount, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientRequestCountMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestCountMinuteView)
 	RPCClientResponseCountMinuteView, _ = stats.NewView("grpc.io/client/response_count/minute_interval", "Minute stats on the count of response messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientResponseCount, aggCount, windowSlidingMinute)
-	clientViews = append(clientViews, RPCClientResponseCountMinuteView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseCountMinuteView)
 
 	RPCClientRoundTripLatencyHourView, _ = stats.NewView("grpc.io/client/roundtrip_latency/hour_interval", "Hour stats for latency in msecs", []tag.Key{keyService, keyMethod}, RPCClientRoundTripLatency, aggDistMillis, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientRoundTripLatencyHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRoundTripLatencyHourView)
 	RPCClientRequestBytesHourView, _ = stats.NewView("grpc.io/client/request_bytes/hour_interval", "Hour stats for request size in bytes", []tag.Key{keyService, keyMethod}, RPCClientRequestBytes, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientRequestBytesHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestBytesHourView)
 	RPCClientResponseBytesHourView, _ = stats.NewView("grpc.io/client/response_bytes/hour_interval", "Hour stats for response size in bytes", []tag.Key{keyService, keyMethod}, RPCClientResponseBytes, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientResponseBytesHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseBytesHourView)
 	RPCClientErrorCountHourView, _ = stats.NewView("grpc.io/client/error_count/hour_interval", "Hour stats for rpc errors", []tag.Key{keyService, keyMethod}, RPCClientErrorCount, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientErrorCountHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientErrorCountHourView)
 	RPCClientStartedCountHourView, _ = stats.NewView("grpc.io/client/started_count/hour_interval", "Hour stats on the number of client RPCs started", []tag.Key{keyService, keyMethod}, RPCClientStartedCount, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientStartedCountHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStartedCountHourView)
 	RPCClientFinishedCountHourView, _ = stats.NewView("grpc.io/client/finished_count/hour_interval", "Hour stats on the number of client RPCs finished", []tag.Key{keyService, keyMethod}, RPCClientFinishedCount, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientFinishedCountHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientFinishedCountHourView)
 	RPCClientRequestCountHourView, _ = stats.NewView("grpc.io/client/request_count/hour_interval", "Hour stats on the count of request messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientRequestCount, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientRequestCountHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestCountHourView)
 	RPCClientResponseCountHourView, _ = stats.NewView("grpc.io/client/response_count/hour_interval", "Hour stats on the count of response messages per client RPC", []tag.Key{keyService, keyMethod}, RPCClientResponseCount, aggCount, windowSlidingHour)
-	clientViews = append(clientViews, RPCClientResponseCountHourView)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseCountHourView)
+
+	RPCClientRoundTripLatencyByStatusView, _ = stats.NewView("grpc.io/client/roundtrip_latency/by_status/distribution_cumulative", "Latency in msecs by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCClientRoundTripLatency, aggDistMillis, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRoundTripLatencyByStatusView)
+	RPCClientRequestCountByStatusView, _ = stats.NewView("grpc.io/client/request_count/by_status/distribution_cumulative", "Count of request messages per client RPC by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCClientRequestCount, aggDistCounts, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientRequestCountByStatusView)
+	RPCClientResponseCountByStatusView, _ = stats.NewView("grpc.io/client/response_count/by_status/distribution_cumulative", "Count of response messages per client RPC by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCClientResponseCount, aggDistCounts, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientResponseCountByStatusView)
+	RPCClientFinishedCountByStatusView, _ = stats.NewView("grpc.io/client/finished_count/by_status/cumulative", "Number of client RPCs finished by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCClientFinishedCount, aggCount, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientFinishedCountByStatusView)
 	// TODO(jbd): Register it when constructing the stats handler.
 }
 
@@ -153,4 +170,7 @@ func initClient() {
 	defaultClientViews()
 }
 
-var clientViews []*stats.View
+// DefaultClientViews are the predefined client views. None of them are subscribed
+// by default; pass the ones to collect to NewClientStatsHandlerWithOptions
+// or subscribe them directly.
+var DefaultClientViews []*stats.View
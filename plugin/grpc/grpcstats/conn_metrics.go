@@ -0,0 +1,94 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpcstats
+
+import (
+	"log"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// The following variables are measures and views made available for gRPC
+// connections. They are recorded by both ClientStatsHandler and
+// ServerStatsHandler and are tagged with the side of the connection and the
+// address families of its endpoints.
+var (
+	// Available connection measures
+	ConnOpenCount   *stats.MeasureInt64
+	ConnOpenedCount *stats.MeasureInt64
+	ConnClosedCount *stats.MeasureInt64
+	ConnLifetime    *stats.MeasureFloat64
+
+	// Predefined connection views
+	ConnOpenView         *stats.View
+	ConnOpenedCountView  *stats.View
+	ConnClosedCountView  *stats.View
+	ConnLifetimeView     *stats.View
+	ConnOpenedMinuteView *stats.View
+	ConnClosedMinuteView *stats.View
+)
+
+var connLifetimeBucketBoundaries = []float64{0, 1, 5, 10, 30, 60, 300, 600, 1800, 3600, 7200, 21600, 43200, 86400}
+
+func defaultConnMeasures() {
+	var err error
+
+	if ConnOpenCount, err = stats.NewMeasureInt64("/grpc.io/conn/open_count", "Change in the number of open connections", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/conn/open_count: %v", err)
+	}
+	if ConnOpenedCount, err = stats.NewMeasureInt64("/grpc.io/conn/opened_count", "Number of connections opened", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/conn/opened_count: %v", err)
+	}
+	if ConnClosedCount, err = stats.NewMeasureInt64("/grpc.io/conn/closed_count", "Number of connections closed", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/conn/closed_count: %v", err)
+	}
+	if ConnLifetime, err = stats.NewMeasureFloat64("/grpc.io/conn/lifetime", "Connection lifetime in secs", unitSecond); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/conn/lifetime: %v", err)
+	}
+}
+
+func defaultConnViews() {
+	keys := []tag.Key{keyConnSide, keyLocalFamily, keyPeerFamily}
+	aggDistLifetime := stats.DistributionAggregation(connLifetimeBucketBoundaries)
+
+	ConnOpenView, _ = stats.NewView("grpc.io/conn/open/sum_cumulative", "Number of open connections", keys, ConnOpenCount, stats.SumAggregation{}, windowCumulative)
+	DefaultConnViews = append(DefaultConnViews, ConnOpenView)
+	ConnOpenedCountView, _ = stats.NewView("grpc.io/conn/opened_count/cumulative", "Number of connections opened", keys, ConnOpenedCount, aggCount, windowCumulative)
+	DefaultConnViews = append(DefaultConnViews, ConnOpenedCountView)
+	ConnClosedCountView, _ = stats.NewView("grpc.io/conn/closed_count/cumulative", "Number of connections closed", keys, ConnClosedCount, aggCount, windowCumulative)
+	DefaultConnViews = append(DefaultConnViews, ConnClosedCountView)
+	ConnLifetimeView, _ = stats.NewView("grpc.io/conn/lifetime/distribution_cumulative", "Connection lifetime in secs", keys, ConnLifetime, aggDistLifetime, windowCumulative)
+	DefaultConnViews = append(DefaultConnViews, ConnLifetimeView)
+
+	ConnOpenedMinuteView, _ = stats.NewView("grpc.io/conn/opened_count/minute_interval", "Minute stats on the number of connections opened", keys, ConnOpenedCount, aggCount, windowSlidingMinute)
+	DefaultConnViews = append(DefaultConnViews, ConnOpenedMinuteView)
+	ConnClosedMinuteView, _ = stats.NewView("grpc.io/conn/closed_count/minute_interval", "Minute stats on the number of connections closed", keys, ConnClosedCount, aggCount, windowSlidingMinute)
+	DefaultConnViews = append(DefaultConnViews, ConnClosedMinuteView)
+}
+
+// initConn registers the default metrics (measures and views)
+// for gRPC connections.
+func initConn() {
+	defaultConnMeasures()
+	defaultConnViews()
+}
+
+// DefaultConnViews are the predefined connection views. None of them are
+// subscribed by default; pass the ones to collect to
+// NewClientStatsHandlerWithOptions or NewServerStatsHandlerWithOptions,
+// or subscribe them directly.
+var DefaultConnViews []*stats.View
@@ -0,0 +1,127 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpcstats
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	istats "go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"google.golang.org/grpc/stats"
+)
+
+func TestConnCollections(t *testing.T) {
+	for _, v := range DefaultConnViews {
+		if err := v.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		defer v.Unsubscribe()
+	}
+
+	v4 := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 443}
+	v6 := &net.TCPAddr{IP: net.ParseIP("::1"), Port: 443}
+	unix := &net.UnixAddr{Name: "/tmp/sock", Net: "unix"}
+
+	conns := []struct {
+		h      stats.Handler
+		info   *stats.ConnTagInfo
+		closed bool
+	}{
+		{&ServerStatsHandler{}, &stats.ConnTagInfo{LocalAddr: v4, RemoteAddr: v4}, true},
+		{&ServerStatsHandler{}, &stats.ConnTagInfo{LocalAddr: v4, RemoteAddr: v4}, false},
+		{&ServerStatsHandler{}, &stats.ConnTagInfo{LocalAddr: v6, RemoteAddr: v6}, false},
+		{&ClientStatsHandler{}, &stats.ConnTagInfo{LocalAddr: unix, RemoteAddr: unix}, true},
+	}
+	for _, c := range conns {
+		ctx := c.h.TagConn(context.Background(), c.info)
+		c.h.HandleConn(ctx, &stats.ConnBegin{})
+		if c.closed {
+			c.h.HandleConn(ctx, &stats.ConnEnd{})
+		}
+	}
+
+	tags := func(side, family string) []tag.Tag {
+		return []tag.Tag{
+			{Key: keyLocalFamily, Value: family},
+			{Key: keyPeerFamily, Value: family},
+			{Key: keyConnSide, Value: side},
+		}
+	}
+	for _, tc := range []struct {
+		v    *istats.View
+		want []*istats.Row
+	}{
+		{
+			ConnOpenView,
+			[]*istats.Row{
+				{Tags: tags("server", "ipv4"), Data: newSumData(1)},
+				{Tags: tags("server", "ipv6"), Data: newSumData(1)},
+				{Tags: tags("client", "unix"), Data: newSumData(0)},
+			},
+		},
+		{
+			ConnOpenedCountView,
+			[]*istats.Row{
+				{Tags: tags("server", "ipv4"), Data: newCountData(2)},
+				{Tags: tags("server", "ipv6"), Data: newCountData(1)},
+				{Tags: tags("client", "unix"), Data: newCountData(1)},
+			},
+		},
+		{
+			ConnClosedCountView,
+			[]*istats.Row{
+				{Tags: tags("server", "ipv4"), Data: newCountData(1)},
+				{Tags: tags("client", "unix"), Data: newCountData(1)},
+			},
+		},
+	} {
+		rows, err := tc.v.RetrieveData()
+		if err != nil {
+			t.Fatalf("%v: RetrieveData = %v", tc.v.Name(), err)
+		}
+		for _, w := range tc.want {
+			found := false
+			for _, r := range rows {
+				if r.Equal(w) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%v: missing row %v; got %v", tc.v.Name(), w, rows)
+			}
+		}
+		if len(rows) != len(tc.want) {
+			t.Errorf("%v: got %d rows, want %d", tc.v.Name(), len(rows), len(tc.want))
+		}
+	}
+
+	rows, err := ConnLifetimeView.RetrieveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("%v: got %d rows, want 2", ConnLifetimeView.Name(), len(rows))
+	}
+}
+
+func newSumData(v float64) *istats.SumData {
+	sd := istats.SumData(v)
+	return &sd
+}
@@ -17,11 +17,22 @@
 package grpcstats // import "go.opencensus.io/plugin/grpc/grpcstats"
 
 import (
+	"fmt"
 	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	istats "go.opencensus.io/stats"
 	"go.opencensus.io/tag"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 )
 
 type grpcInstrumentationKey string
@@ -35,8 +46,51 @@ type rpcData struct {
 	// application code invoked GRPC code.
 	startTime           time.Time
 	reqCount, respCount int64 // access atomically
+	reqBytes, respBytes int64 // access atomically
+
+	// stream is set to 1 by the Begin event of a streaming RPC.
+	stream int32 // access atomically
+	// firstResp is set to 1 when the first response message is seen.
+	firstResp int32 // access atomically
+}
+
+// handleBegin marks d as streaming if s is the Begin event of a streaming
+// RPC.
+func (d *rpcData) handleBegin(s *stats.Begin) {
+	if s.IsClientStream || s.IsServerStream {
+		atomic.StoreInt32(&d.stream, 1)
+	}
+}
+
+// isStream reports whether d is the data of a streaming RPC.
+func (d *rpcData) isStream() bool {
+	return atomic.LoadInt32(&d.stream) == 1
+}
+
+// isFirstResponse reports whether it is the first time it is called for d,
+// which is at the first response message.
+func (d *rpcData) isFirstResponse() bool {
+	return atomic.CompareAndSwapInt32(&d.firstResp, 0, 1)
+}
+
+// streamMeasurements returns the measurements of the stream measures
+// for d, or nil if d is not the data of a streaming RPC.
+func (d *rpcData) streamMeasurements(reqCount, respCount, reqBytes, respBytes *istats.MeasureInt64) []istats.Measurement {
+	if !d.isStream() {
+		return nil
+	}
+	return []istats.Measurement{
+		reqCount.M(atomic.LoadInt64(&d.reqCount)),
+		respCount.M(atomic.LoadInt64(&d.respCount)),
+		reqBytes.M(atomic.LoadInt64(&d.reqBytes)),
+		respBytes.M(atomic.LoadInt64(&d.respBytes)),
+	}
 }
 
+// tagsBinHeader is the metadata key under which the encoded tags of the
+// caller are propagated to the server.
+const tagsBinHeader = "grpc-tags-bin"
+
 // The following variables define the default hard-coded auxiliary data used by
 // both the default GRPC client and GRPC server metrics.
 // These are Go objects instances mirroring the some of the proto definitions
@@ -49,6 +103,7 @@ var (
 	unitByte             = "By"
 	unitCount            = "1"
 	unitMillisecond      = "ms"
+	unitSecond           = "s"
 	slidingTimeSubuckets = 6
 
 	rpcBytesBucketBoundaries  = []float64{0, 1024, 2048, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864, 268435456, 1073741824, 4294967296}
@@ -67,6 +122,10 @@ var (
 	keyService  tag.Key
 	keyMethod   tag.Key
 	keyOpStatus tag.Key
+
+	keyConnSide    tag.Key
+	keyLocalFamily tag.Key
+	keyPeerFamily  tag.Key
 )
 
 func init() {
@@ -80,12 +139,164 @@ func init() {
 	if keyOpStatus, err = tag.NewKey("grpc.opstatus"); err != nil {
 		log.Fatalf("Cannot create grpc.opstatus key: %v", err)
 	}
+	if keyConnSide, err = tag.NewKey("grpc.conn.side"); err != nil {
+		log.Fatalf("Cannot create grpc.conn.side key: %v", err)
+	}
+	if keyLocalFamily, err = tag.NewKey("grpc.conn.local_family"); err != nil {
+		log.Fatalf("Cannot create grpc.conn.local_family key: %v", err)
+	}
+	if keyPeerFamily, err = tag.NewKey("grpc.conn.peer_family"); err != nil {
+		log.Fatalf("Cannot create grpc.conn.peer_family key: %v", err)
+	}
 	initServer()
 	initClient()
+	initStream()
+	initConn()
 }
 
 var (
 	grpcServerConnKey = grpcInstrumentationKey("server-conn")
+	grpcClientConnKey = grpcInstrumentationKey("client-conn")
 	grpcServerRPCKey  = grpcInstrumentationKey("server-rpc")
 	grpcClientRPCKey  = grpcInstrumentationKey("client-rpc")
 )
+
+// subscribeViews subscribes to each of the views.
+func subscribeViews(views []*istats.View) error {
+	for _, v := range views {
+		if v == nil {
+			return fmt.Errorf("grpcstats: cannot subscribe to nil view")
+		}
+		if err := v.Subscribe(); err != nil {
+			return fmt.Errorf("grpcstats: cannot subscribe to view %q: %v", v.Name(), err)
+		}
+	}
+	return nil
+}
+
+// metadataMutators returns mutators upserting the first value of each
+// metadata key in keys under the corresponding tag key.
+func metadataMutators(md metadata.MD, keys map[string]tag.Key) []tag.Mutator {
+	var mods []tag.Mutator
+	for mk, tk := range keys {
+		if v := md[strings.ToLower(mk)]; len(v) > 0 {
+			mods = append(mods, tag.Upsert(tk, v[0]))
+		}
+	}
+	return mods
+}
+
+// peerHost returns the host part of addr, or the whole address if it has
+// no port.
+func peerHost(addr net.Addr) string {
+	s := addr.String()
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return host
+	}
+	return s
+}
+
+// statusCodeNames are the canonical names of the gRPC status codes.
+var statusCodeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+// statusCodeName returns the canonical name of the gRPC status code of
+// err, which is "OK" if err is nil.
+func statusCodeName(err error) string {
+	c := grpc.Code(err)
+	if name, ok := statusCodeNames[c]; ok {
+		return name
+	}
+	return "CODE_" + strconv.FormatUint(uint64(c), 10)
+}
+
+// connData holds the data that is needed between the start and end of a
+// connection.
+type connData struct {
+	// startTime is the time at which TagConn was invoked for the
+	// connection.
+	startTime time.Time
+	tags      *tag.Map
+}
+
+// tagConn returns ctx with the connData of the connection described by
+// info added under key.
+func tagConn(ctx context.Context, key grpcInstrumentationKey, side string, info *stats.ConnTagInfo) context.Context {
+	if info == nil {
+		return ctx
+	}
+	// The tags are kept in connData rather than in ctx because the contexts
+	// of the RPCs of a server connection are derived from ctx.
+	tags, err := tag.NewMap(ctx,
+		tag.Upsert(keyConnSide, side),
+		tag.Upsert(keyLocalFamily, addrFamily(info.LocalAddr)),
+		tag.Upsert(keyPeerFamily, addrFamily(info.RemoteAddr)),
+	)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, key, &connData{startTime: time.Now(), tags: tags})
+}
+
+// handleConn records the connection measures for the connection whose
+// connData is in ctx under key.
+func handleConn(ctx context.Context, key grpcInstrumentationKey, s stats.ConnStats) {
+	d, ok := ctx.Value(key).(*connData)
+	if !ok {
+		if grpclog.V(2) {
+			grpclog.Infoln("handleConn failed to retrieve *connData from context")
+		}
+		return
+	}
+	ctx = tag.NewContext(ctx, d.tags)
+	switch s.(type) {
+	case *stats.ConnBegin:
+		istats.Record(ctx, ConnOpenCount.M(1), ConnOpenedCount.M(1))
+	case *stats.ConnEnd:
+		istats.Record(ctx,
+			ConnOpenCount.M(-1),
+			ConnClosedCount.M(1),
+			ConnLifetime.M(time.Since(d.startTime).Seconds()))
+	}
+}
+
+// addrFamily returns the address family of addr: "ipv4", "ipv6", "unix",
+// or the network name of addr for other addresses.
+func addrFamily(addr net.Addr) string {
+	var ip net.IP
+	switch a := addr.(type) {
+	case nil:
+		return "unknown"
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.IPAddr:
+		ip = a.IP
+	case *net.UnixAddr:
+		return "unix"
+	default:
+		return addr.Network()
+	}
+	if ip.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
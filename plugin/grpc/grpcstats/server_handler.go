@@ -26,13 +26,41 @@ import (
 	istats "go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/stats"
 )
 
 // ServerStatsHandler is a stats.Handler implementation
 // that collects stats for a gRPC server. Predefined
 // measures and views can be used to access the collected data.
-type ServerStatsHandler struct{}
+type ServerStatsHandler struct {
+	o ServerOptions
+}
+
+// ServerOptions contains options for a ServerStatsHandler.
+type ServerOptions struct {
+	// MetadataTags maps incoming metadata keys to the tag keys their
+	// values are recorded under. Only the first value of a metadata key
+	// is used.
+	MetadataTags map[string]tag.Key
+
+	// PeerKey, if set, is the tag key the peer's host address is
+	// recorded under.
+	PeerKey tag.Key
+
+	// AuthorityKey, if set, is the tag key the :authority of the
+	// request is recorded under.
+	AuthorityKey tag.Key
+
+	// DisableTagPropagation causes the tags sent by the client to be
+	// ignored.
+	DisableTagPropagation bool
+
+	// Views are subscribed to when the handler is created.
+	// DefaultServerViews lists the predefined server views.
+	Views []*istats.View
+}
 
 var _ stats.Handler = &ServerStatsHandler{}
 
@@ -43,16 +71,25 @@ func NewServerStatsHandler() *ServerStatsHandler {
 	return &ServerStatsHandler{}
 }
 
+// NewServerStatsHandlerWithOptions is like NewServerStatsHandler but
+// configures the handler with o. It returns an error if any of o.Views
+// cannot be subscribed to.
+func NewServerStatsHandlerWithOptions(o ServerOptions) (*ServerStatsHandler, error) {
+	if err := subscribeViews(o.Views); err != nil {
+		return nil, err
+	}
+	return &ServerStatsHandler{o: o}, nil
+}
+
 // TagConn adds connection related data to the given context and returns the
 // new context.
 func (h *ServerStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
-	// Do nothing. This is here to satisfy the interface "google.golang.org/grpc/stats.Handler"
-	return ctx
+	return tagConn(ctx, grpcServerConnKey, "server", info)
 }
 
 // HandleConn processes the connection events.
 func (h *ServerStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
-	// Do nothing. This is here to satisfy the interface "google.golang.org/grpc/stats.Handler"
+	handleConn(ctx, grpcServerConnKey, s)
 }
 
 // TagRPC gets the metadata from gRPC context, extracts the encoded tags from
@@ -92,7 +129,9 @@ func (h *ServerStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo)
 // HandleRPC processes the RPC events.
 func (h *ServerStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
 	switch st := s.(type) {
-	case *stats.Begin, *stats.InHeader, *stats.InTrailer, *stats.OutHeader, *stats.OutTrailer:
+	case *stats.Begin:
+		h.handleRPCBegin(ctx, st)
+	case *stats.InHeader, *stats.InTrailer, *stats.OutHeader, *stats.OutTrailer:
 		// Do nothing for server
 	case *stats.InPayload:
 		h.handleRPCInPayload(ctx, st)
@@ -106,6 +145,17 @@ func (h *ServerStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
 	}
 }
 
+func (h *ServerStatsHandler) handleRPCBegin(ctx context.Context, s *stats.Begin) {
+	d, ok := ctx.Value(grpcServerRPCKey).(*rpcData)
+	if !ok {
+		if grpclog.V(2) {
+			grpclog.Infoln("serverHandler.handleRPCBegin failed to retrieve *rpcData from context")
+		}
+		return
+	}
+	d.handleBegin(s)
+}
+
 func (h *ServerStatsHandler) handleRPCInPayload(ctx context.Context, s *stats.InPayload) {
 	d, ok := ctx.Value(grpcServerRPCKey).(*rpcData)
 	if !ok {
@@ -116,6 +166,7 @@ func (h *ServerStatsHandler) handleRPCInPayload(ctx context.Context, s *stats.In
 	}
 
 	istats.Record(ctx, RPCServerRequestBytes.M(int64(s.Length)))
+	atomic.AddInt64(&d.reqBytes, int64(s.Length))
 	atomic.AddInt64(&d.reqCount, 1)
 }
 
@@ -128,7 +179,12 @@ func (h *ServerStatsHandler) handleRPCOutPayload(ctx context.Context, s *stats.O
 		return
 	}
 
-	istats.Record(ctx, RPCServerResponseBytes.M(int64(s.Length)))
+	m := []istats.Measurement{RPCServerResponseBytes.M(int64(s.Length))}
+	if d.isFirstResponse() {
+		m = append(m, RPCServerTimeToFirstResponse.M(float64(time.Since(d.startTime))/float64(time.Millisecond)))
+	}
+	istats.Record(ctx, m...)
+	atomic.AddInt64(&d.respBytes, int64(s.Length))
 	atomic.AddInt64(&d.respCount, 1)
 }
 
@@ -150,16 +206,18 @@ func (h *ServerStatsHandler) handleRPCEnd(ctx context.Context, s *stats.End) {
 	m = append(m, RPCServerResponseCount.M(respCount))
 	m = append(m, RPCServerFinishedCount.M(1))
 	m = append(m, RPCServerServerElapsedTime.M(float64(elapsedTime)/float64(time.Millisecond)))
+	// The status tag is recorded for successful RPCs too so that error
+	// ratios can be computed from a single view.
+	tm, err := tag.NewMap(ctx,
+		tag.Upsert(keyOpStatus, statusCodeName(s.Error)),
+	)
+	if err == nil {
+		ctx = tag.NewContext(ctx, tm)
+	}
 	if s.Error != nil {
-		errorCode := s.Error.Error()
-		tm, err := tag.NewMap(ctx,
-			tag.Upsert(keyOpStatus, errorCode),
-		)
-		if err == nil {
-			ctx = tag.NewContext(ctx, tm)
-		}
 		m = append(m, RPCServerErrorCount.M(1))
 	}
+	m = append(m, d.streamMeasurements(RPCServerStreamRequestCount, RPCServerStreamResponseCount, RPCServerStreamRequestBytes, RPCServerStreamResponseBytes)...)
 
 	istats.Record(ctx, m...)
 }
@@ -171,7 +229,23 @@ func (h *ServerStatsHandler) createTagMap(ctx context.Context, serviceName, meth
 		tag.Upsert(keyService, serviceName),
 		tag.Upsert(keyMethod, methodName),
 	}
-	if tagsBin := stats.Tags(ctx); tagsBin != nil {
+	md, _ := metadata.FromIncomingContext(ctx)
+	mods = append(mods, metadataMutators(md, h.o.MetadataTags)...)
+	if h.o.AuthorityKey != (tag.Key{}) {
+		if v := md[":authority"]; len(v) > 0 {
+			mods = append(mods, tag.Upsert(h.o.AuthorityKey, v[0]))
+		}
+	}
+	if h.o.PeerKey != (tag.Key{}) {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			mods = append(mods, tag.Upsert(h.o.PeerKey, peerHost(p.Addr)))
+		}
+	}
+	if h.o.DisableTagPropagation {
+		return tag.NewMap(ctx, mods...)
+	}
+	if v := md[tagsBinHeader]; len(v) > 0 {
+		tagsBin := v[len(v)-1]
 		old, err := tag.Decode([]byte(tagsBin))
 		if err != nil {
 			return nil, fmt.Errorf("serverHandler.createTagMap failed to decode tagsBin %v: %v", tagsBin, err)
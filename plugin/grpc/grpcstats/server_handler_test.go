@@ -16,7 +16,7 @@
 package grpcstats
 
 import (
-	"errors"
+	"net"
 	"testing"
 
 	"golang.org/x/net/context"
@@ -24,6 +24,10 @@ import (
 	istats "go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/stats"
 )
 
@@ -82,6 +86,19 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCServerResponseCountView },
 					[]*istats.Row{
@@ -94,6 +111,19 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCServerRequestBytesView },
 					[]*istats.Row{
@@ -147,7 +177,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{Length: 10},
 						{Length: 10},
 					},
-					&stats.End{Error: errors.New("someError")},
+					&stats.End{Error: grpc.Errorf(codes.Internal, "someError")},
 				},
 			},
 			[]*wantData{
@@ -157,7 +187,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -176,6 +206,27 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCServerResponseCountView },
 					[]*istats.Row{
@@ -188,6 +239,27 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+					},
+				},
 			},
 		},
 		{
@@ -216,7 +288,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{Length: 4096},
 						{Length: 16384},
 					},
-					&stats.End{Error: errors.New("someError1")},
+					&stats.End{Error: grpc.Errorf(codes.Unavailable, "someError1")},
 				},
 				{
 					[]tagPair{{k1, "v11"}, {k2, "v22"}},
@@ -230,7 +302,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{Length: 4096},
 						{Length: 16384},
 					},
-					&stats.End{Error: errors.New("someError2")},
+					&stats.End{Error: grpc.Errorf(codes.Internal, "someError2")},
 				},
 			},
 			[]*wantData{
@@ -240,7 +312,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError1"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -248,7 +320,7 @@ func TestServerDefaultCollections(t *testing.T) {
 						{
 							[]tag.Tag{
 								{Key: keyMethod, Value: "method"},
-								{Key: keyOpStatus, Value: "someError2"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
 								{Key: keyService, Value: "package.service"},
 							},
 							newCountData(1),
@@ -267,6 +339,35 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerRequestCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 1, 1, 1, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCServerResponseCountView },
 					[]*istats.Row{
@@ -279,6 +380,35 @@ func TestServerDefaultCollections(t *testing.T) {
 						},
 					},
 				},
+				{
+					func() *istats.View { return RPCServerResponseCountByStatusView },
+					[]*istats.Row{
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "INTERNAL"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "OK"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 3, 3, 3, 0),
+						},
+						{
+							[]tag.Tag{
+								{Key: keyMethod, Value: "method"},
+								{Key: keyOpStatus, Value: "UNAVAILABLE"},
+								{Key: keyService, Value: "package.service"},
+							},
+							newDistributionData(rpcCountBucketBoundaries, []int64{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 1, 2, 2, 2, 0),
+						},
+					},
+				},
 				{
 					func() *istats.View { return RPCServerRequestBytesView },
 					[]*istats.Row{
@@ -308,7 +438,7 @@ func TestServerDefaultCollections(t *testing.T) {
 	}
 
 	for _, tc := range tcs {
-		for _, v := range serverViews {
+		for _, v := range DefaultServerViews {
 			if err := v.Subscribe(); err != nil {
 				t.Error(err)
 			}
@@ -363,7 +493,7 @@ func TestServerDefaultCollections(t *testing.T) {
 		}
 
 		// Unregister views to cleanup.
-		for _, v := range serverViews {
+		for _, v := range DefaultServerViews {
 			if err := v.Unsubscribe(); err != nil {
 				t.Error(err)
 			}
@@ -386,3 +516,75 @@ func newDistributionData(bounds []float64, countPerBucket []int64, count int64,
 		CountPerBucket:  countPerBucket,
 	}
 }
+
+func TestServerOptions(t *testing.T) {
+	k1, _ := tag.NewKey("k1")
+	user, _ := tag.NewKey("user")
+	peerKey, _ := tag.NewKey("peer")
+	authority, _ := tag.NewKey("authority")
+
+	ts, err := tag.NewMap(context.Background(), tag.Upsert(k1, "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-user", "alice",
+		":authority", "example.com:443",
+		"grpc-tags-bin", string(tag.Encode(ts)),
+	))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+	info := &stats.RPCTagInfo{FullMethodName: "/package.service/method"}
+
+	tcs := []struct {
+		label string
+		o     ServerOptions
+		want  map[tag.Key]string
+	}{
+		{
+			label: "default",
+			want:  map[tag.Key]string{k1: "v1", keyService: "package.service", keyMethod: "method"},
+		},
+		{
+			label: "metadata, peer and authority",
+			o: ServerOptions{
+				MetadataTags: map[string]tag.Key{"X-User": user},
+				PeerKey:      peerKey,
+				AuthorityKey: authority,
+			},
+			want: map[tag.Key]string{k1: "v1", keyService: "package.service", keyMethod: "method", user: "alice", peerKey: "10.0.0.1", authority: "example.com:443"},
+		},
+		{
+			label: "no propagation",
+			o:     ServerOptions{DisableTagPropagation: true},
+			want:  map[tag.Key]string{keyService: "package.service", keyMethod: "method"},
+		},
+	}
+	for _, tc := range tcs {
+		h, err := NewServerStatsHandlerWithOptions(tc.o)
+		if err != nil {
+			t.Fatalf("%v: NewServerStatsHandlerWithOptions = %v", tc.label, err)
+		}
+		got := tag.FromContext(h.TagRPC(ctx, info))
+		for _, k := range []tag.Key{k1, user, peerKey, authority, keyService, keyMethod} {
+			v, ok := got.Value(k)
+			want, wantOK := tc.want[k]
+			if ok != wantOK || v != want {
+				t.Errorf("%v: tag %v = %q, %v; want %q, %v", tc.label, k.Name(), v, ok, want, wantOK)
+			}
+		}
+	}
+}
+
+func TestServerOptionsViews(t *testing.T) {
+	v := RPCServerStartedCountMinuteView
+	if _, err := NewServerStatsHandlerWithOptions(ServerOptions{Views: []*istats.View{v}}); err != nil {
+		t.Fatalf("NewServerStatsHandlerWithOptions = %v", err)
+	}
+	defer v.Unsubscribe()
+	if _, err := v.RetrieveData(); err != nil {
+		t.Errorf("RetrieveData = %v; want the view to be subscribed", err)
+	}
+	if _, err := NewServerStatsHandlerWithOptions(ServerOptions{Views: []*istats.View{nil}}); err == nil {
+		t.Error("NewServerStatsHandlerWithOptions with a nil view = nil; want error")
+	}
+}
@@ -60,6 +60,14 @@ var (
 	RPCServerFinishedCountHourView     *stats.View
 	RPCServerRequestCountHourView      *stats.View
 	RPCServerResponseCountHourView     *stats.View
+
+	// Predefined server views broken down by status code. They are
+	// separate from the views above so that the tag keys of those do not
+	// change for existing users.
+	RPCServerServerElapsedTimeByStatusView *stats.View
+	RPCServerRequestCountByStatusView      *stats.View
+	RPCServerResponseCountByStatusView     *stats.View
+	RPCServerFinishedCountByStatusView     *stats.View
 )
 
 // TODO(acetechnologist): This is temporary and will need to be replaced by a
@@ -98,51 +106,60 @@ func defaultServerMeasures() {
 
 func defaultServerserverViews() {
 	RPCServerErrorCountView, _ = stats.NewView("grpc.io/server/error_count/distribution_cumulative", "RPC Errors", []tag.Key{keyMethod, keyOpStatus, keyService}, RPCServerErrorCount, aggCount, windowCumulative)
-	serverViews = append(serverViews, RPCServerErrorCountView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerErrorCountView)
 	RPCServerServerElapsedTimeView, _ = stats.NewView("grpc.io/server/server_elapsed_time/distribution_cumulative", "Server elapsed time in msecs", []tag.Key{keyService, keyMethod}, RPCServerServerElapsedTime, aggDistMillis, windowCumulative)
-	serverViews = append(serverViews, RPCServerServerElapsedTimeView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerServerElapsedTimeView)
 	RPCServerRequestBytesView, _ = stats.NewView("grpc.io/server/request_bytes/distribution_cumulative", "Request bytes", []tag.Key{keyService, keyMethod}, RPCServerRequestBytes, aggDistBytes, windowCumulative)
-	serverViews = append(serverViews, RPCServerRequestBytesView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestBytesView)
 	RPCServerResponseBytesView, _ = stats.NewView("grpc.io/server/response_bytes/distribution_cumulative", "Response bytes", []tag.Key{keyService, keyMethod}, RPCServerResponseBytes, aggDistBytes, windowCumulative)
-	serverViews = append(serverViews, RPCServerResponseBytesView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseBytesView)
 	RPCServerRequestCountView, _ = stats.NewView("grpc.io/server/request_count/distribution_cumulative", "Count of request messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerRequestCount, aggDistCounts, windowCumulative)
-	serverViews = append(serverViews, RPCServerRequestCountView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestCountView)
 	RPCServerResponseCountView, _ = stats.NewView("grpc.io/server/response_count/distribution_cumulative", "Count of response messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerResponseCount, aggDistCounts, windowCumulative)
-	serverViews = append(serverViews, RPCServerResponseCountView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseCountView)
 
 	RPCServerServerElapsedTimeMinuteView, _ = stats.NewView("grpc.io/server/server_elapsed_time/minute_interval", "Minute stats for server elapsed time in msecs", []tag.Key{keyService, keyMethod}, RPCServerServerElapsedTime, aggDistMillis, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerServerElapsedTimeMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerServerElapsedTimeMinuteView)
 	RPCServerRequestBytesMinuteView, _ = stats.NewView("grpc.io/server/request_bytes/minute_interval", "Minute stats for request size in bytes", []tag.Key{keyService, keyMethod}, RPCServerRequestBytes, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerRequestBytesMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestBytesMinuteView)
 	RPCServerResponseBytesMinuteView, _ = stats.NewView("grpc.io/server/response_bytes/minute_interval", "Minute stats for response size in bytes", []tag.Key{keyService, keyMethod}, RPCServerResponseBytes, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerResponseBytesMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseBytesMinuteView)
 	RPCServerErrorCountMinuteView, _ = stats.NewView("grpc.io/server/error_count/minute_interval", "Minute stats for rpc errors", []tag.Key{keyService, keyMethod}, RPCServerErrorCount, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerErrorCountMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerErrorCountMinuteView)
 	RPCServerStartedCountMinuteView, _ = stats.NewView("grpc.io/server/started_count/minute_interval", "Minute stats on the number of server RPCs started", []tag.Key{keyService, keyMethod}, RPCServerStartedCount, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerStartedCountMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStartedCountMinuteView)
 	RPCServerFinishedCountMinuteView, _ = stats.NewView("grpc.io/server/finished_count/minute_interval", "Minute stats on the number of server RPCs finished", []tag.Key{keyService, keyMethod}, RPCServerFinishedCount, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerFinishedCountMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerFinishedCountMinuteView)
 	RPCServerRequestCountMinuteView, _ = stats.NewView("grpc.io/server/request_count/minute_interval", "Minute stats on the count of request messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerRequestCount, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerRequestCountMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestCountMinuteView)
 	RPCServerResponseCountMinuteView, _ = stats.NewView("grpc.io/server/response_count/minute_interval", "Minute stats on the count of response messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerResponseCount, aggCount, windowSlidingMinute)
-	serverViews = append(serverViews, RPCServerResponseCountMinuteView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseCountMinuteView)
 
 	RPCServerServerElapsedTimeHourView, _ = stats.NewView("grpc.io/server/server_elapsed_time/hour_interval", "Hour stats for server elapsed time in msecs", []tag.Key{keyService, keyMethod}, RPCServerServerElapsedTime, aggDistMillis, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerServerElapsedTimeHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerServerElapsedTimeHourView)
 	RPCServerRequestBytesHourView, _ = stats.NewView("grpc.io/server/request_bytes/hour_interval", "Hour stats for request size in bytes", []tag.Key{keyService, keyMethod}, RPCServerRequestBytes, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerRequestBytesHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestBytesHourView)
 	RPCServerResponseBytesHourView, _ = stats.NewView("grpc.io/server/response_bytes/hour_interval", "Hour stats for response size in bytes", []tag.Key{keyService, keyMethod}, RPCServerResponseBytes, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerResponseBytesHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseBytesHourView)
 	RPCServerErrorCountHourView, _ = stats.NewView("grpc.io/server/error_count/hour_interval", "Hour stats for rpc errors", []tag.Key{keyService, keyMethod}, RPCServerErrorCount, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerErrorCountHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerErrorCountHourView)
 	RPCServerStartedCountHourView, _ = stats.NewView("grpc.io/server/started_count/hour_interval", "Hour stats on the number of server RPCs started", []tag.Key{keyService, keyMethod}, RPCServerStartedCount, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerStartedCountHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStartedCountHourView)
 	RPCServerFinishedCountHourView, _ = stats.NewView("grpc.io/server/finished_count/hour_interval", "Hour stats on the number of server RPCs finished", []tag.Key{keyService, keyMethod}, RPCServerFinishedCount, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerFinishedCountHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerFinishedCountHourView)
 	RPCServerRequestCountHourView, _ = stats.NewView("grpc.io/server/request_count/hour_interval", "Hour stats on the count of request messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerRequestCount, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerRequestCountHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestCountHourView)
 	RPCServerResponseCountHourView, _ = stats.NewView("grpc.io/server/response_count/hour_interval", "Hour stats on the count of response messages per server RPC", []tag.Key{keyService, keyMethod}, RPCServerResponseCount, aggCount, windowSlidingHour)
-	serverViews = append(serverViews, RPCServerResponseCountHourView)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseCountHourView)
+
+	RPCServerServerElapsedTimeByStatusView, _ = stats.NewView("grpc.io/server/server_elapsed_time/by_status/distribution_cumulative", "Server elapsed time in msecs by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCServerServerElapsedTime, aggDistMillis, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerServerElapsedTimeByStatusView)
+	RPCServerRequestCountByStatusView, _ = stats.NewView("grpc.io/server/request_count/by_status/distribution_cumulative", "Count of request messages per server RPC by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCServerRequestCount, aggDistCounts, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerRequestCountByStatusView)
+	RPCServerResponseCountByStatusView, _ = stats.NewView("grpc.io/server/response_count/by_status/distribution_cumulative", "Count of response messages per server RPC by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCServerResponseCount, aggDistCounts, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerResponseCountByStatusView)
+	RPCServerFinishedCountByStatusView, _ = stats.NewView("grpc.io/server/finished_count/by_status/cumulative", "Number of server RPCs finished by status code", []tag.Key{keyService, keyMethod, keyOpStatus}, RPCServerFinishedCount, aggCount, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerFinishedCountByStatusView)
 }
 
 func initServer() {
@@ -150,4 +167,7 @@ func initServer() {
 	defaultServerserverViews()
 }
 
-var serverViews []*stats.View
+// DefaultServerViews are the predefined server views. None of them are subscribed
+// by default; pass the ones to collect to NewServerStatsHandlerWithOptions
+// or subscribe them directly.
+var DefaultServerViews []*stats.View
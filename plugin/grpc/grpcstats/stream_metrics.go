@@ -0,0 +1,128 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpcstats
+
+import (
+	"log"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// The following variables are measures and views made available for
+// streaming RPCs, in which the client, the server or both send a stream of
+// messages. The per-stream measures are only recorded for streaming RPCs;
+// the time to the first response is recorded for all RPCs.
+var (
+	// Available client stream measures
+	RPCClientStreamRequestCount  *stats.MeasureInt64
+	RPCClientStreamResponseCount *stats.MeasureInt64
+	RPCClientStreamRequestBytes  *stats.MeasureInt64
+	RPCClientStreamResponseBytes *stats.MeasureInt64
+	RPCClientTimeToFirstResponse *stats.MeasureFloat64
+
+	// Available server stream measures
+	RPCServerStreamRequestCount  *stats.MeasureInt64
+	RPCServerStreamResponseCount *stats.MeasureInt64
+	RPCServerStreamRequestBytes  *stats.MeasureInt64
+	RPCServerStreamResponseBytes *stats.MeasureInt64
+	RPCServerTimeToFirstResponse *stats.MeasureFloat64
+
+	// Predefined client stream views
+	RPCClientStreamRequestCountView  *stats.View
+	RPCClientStreamResponseCountView *stats.View
+	RPCClientStreamRequestBytesView  *stats.View
+	RPCClientStreamResponseBytesView *stats.View
+	RPCClientTimeToFirstResponseView *stats.View
+
+	// Predefined server stream views
+	RPCServerStreamRequestCountView  *stats.View
+	RPCServerStreamResponseCountView *stats.View
+	RPCServerStreamRequestBytesView  *stats.View
+	RPCServerStreamResponseBytesView *stats.View
+	RPCServerTimeToFirstResponseView *stats.View
+)
+
+func defaultStreamMeasures() {
+	var err error
+
+	// Creating client stream measures
+	if RPCClientStreamRequestCount, err = stats.NewMeasureInt64("/grpc.io/client/stream_request_count", "Number of request messages per client stream", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/client/stream_request_count: %v", err)
+	}
+	if RPCClientStreamResponseCount, err = stats.NewMeasureInt64("/grpc.io/client/stream_response_count", "Number of response messages per client stream", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/client/stream_response_count: %v", err)
+	}
+	if RPCClientStreamRequestBytes, err = stats.NewMeasureInt64("/grpc.io/client/stream_request_bytes", "Request bytes per client stream", unitByte); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/client/stream_request_bytes: %v", err)
+	}
+	if RPCClientStreamResponseBytes, err = stats.NewMeasureInt64("/grpc.io/client/stream_response_bytes", "Response bytes per client stream", unitByte); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/client/stream_response_bytes: %v", err)
+	}
+	if RPCClientTimeToFirstResponse, err = stats.NewMeasureFloat64("/grpc.io/client/time_to_first_response", "Time until the first response message is received in msecs", unitMillisecond); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/client/time_to_first_response: %v", err)
+	}
+
+	// Creating server stream measures
+	if RPCServerStreamRequestCount, err = stats.NewMeasureInt64("/grpc.io/server/stream_request_count", "Number of request messages per server stream", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/server/stream_request_count: %v", err)
+	}
+	if RPCServerStreamResponseCount, err = stats.NewMeasureInt64("/grpc.io/server/stream_response_count", "Number of response messages per server stream", unitCount); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/server/stream_response_count: %v", err)
+	}
+	if RPCServerStreamRequestBytes, err = stats.NewMeasureInt64("/grpc.io/server/stream_request_bytes", "Request bytes per server stream", unitByte); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/server/stream_request_bytes: %v", err)
+	}
+	if RPCServerStreamResponseBytes, err = stats.NewMeasureInt64("/grpc.io/server/stream_response_bytes", "Response bytes per server stream", unitByte); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/server/stream_response_bytes: %v", err)
+	}
+	if RPCServerTimeToFirstResponse, err = stats.NewMeasureFloat64("/grpc.io/server/time_to_first_response", "Time until the first response message is sent in msecs", unitMillisecond); err != nil {
+		log.Fatalf("Cannot create measure /grpc.io/server/time_to_first_response: %v", err)
+	}
+}
+
+func defaultStreamViews() {
+	keys := []tag.Key{keyService, keyMethod, keyOpStatus}
+
+	RPCClientStreamRequestCountView, _ = stats.NewView("grpc.io/client/stream_request_count/distribution_cumulative", "Count of request messages per client stream", keys, RPCClientStreamRequestCount, aggDistCounts, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStreamRequestCountView)
+	RPCClientStreamResponseCountView, _ = stats.NewView("grpc.io/client/stream_response_count/distribution_cumulative", "Count of response messages per client stream", keys, RPCClientStreamResponseCount, aggDistCounts, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStreamResponseCountView)
+	RPCClientStreamRequestBytesView, _ = stats.NewView("grpc.io/client/stream_request_bytes/distribution_cumulative", "Request bytes per client stream", keys, RPCClientStreamRequestBytes, aggDistBytes, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStreamRequestBytesView)
+	RPCClientStreamResponseBytesView, _ = stats.NewView("grpc.io/client/stream_response_bytes/distribution_cumulative", "Response bytes per client stream", keys, RPCClientStreamResponseBytes, aggDistBytes, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientStreamResponseBytesView)
+	RPCClientTimeToFirstResponseView, _ = stats.NewView("grpc.io/client/time_to_first_response/distribution_cumulative", "Time until the first response message is received in msecs", []tag.Key{keyService, keyMethod}, RPCClientTimeToFirstResponse, aggDistMillis, windowCumulative)
+	DefaultClientViews = append(DefaultClientViews, RPCClientTimeToFirstResponseView)
+
+	RPCServerStreamRequestCountView, _ = stats.NewView("grpc.io/server/stream_request_count/distribution_cumulative", "Count of request messages per server stream", keys, RPCServerStreamRequestCount, aggDistCounts, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStreamRequestCountView)
+	RPCServerStreamResponseCountView, _ = stats.NewView("grpc.io/server/stream_response_count/distribution_cumulative", "Count of response messages per server stream", keys, RPCServerStreamResponseCount, aggDistCounts, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStreamResponseCountView)
+	RPCServerStreamRequestBytesView, _ = stats.NewView("grpc.io/server/stream_request_bytes/distribution_cumulative", "Request bytes per server stream", keys, RPCServerStreamRequestBytes, aggDistBytes, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStreamRequestBytesView)
+	RPCServerStreamResponseBytesView, _ = stats.NewView("grpc.io/server/stream_response_bytes/distribution_cumulative", "Response bytes per server stream", keys, RPCServerStreamResponseBytes, aggDistBytes, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerStreamResponseBytesView)
+	RPCServerTimeToFirstResponseView, _ = stats.NewView("grpc.io/server/time_to_first_response/distribution_cumulative", "Time until the first response message is sent in msecs", []tag.Key{keyService, keyMethod}, RPCServerTimeToFirstResponse, aggDistMillis, windowCumulative)
+	DefaultServerViews = append(DefaultServerViews, RPCServerTimeToFirstResponseView)
+}
+
+// initStream registers the default metrics (measures and views)
+// for streaming RPCs.
+func initStream() {
+	defaultStreamMeasures()
+	defaultStreamViews()
+}
@@ -0,0 +1,115 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpcstats
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	istats "go.opencensus.io/stats"
+
+	"google.golang.org/grpc/stats"
+)
+
+func TestStreamCollections(t *testing.T) {
+	views := []*istats.View{
+		RPCServerStreamRequestCountView,
+		RPCServerStreamResponseCountView,
+		RPCServerStreamRequestBytesView,
+		RPCServerStreamResponseBytesView,
+		RPCServerTimeToFirstResponseView,
+		RPCClientStreamRequestCountView,
+		RPCClientTimeToFirstResponseView,
+	}
+	for _, v := range views {
+		if err := v.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		defer v.Unsubscribe()
+	}
+
+	info := &stats.RPCTagInfo{FullMethodName: "/package.service/method"}
+	server := &ServerStatsHandler{}
+	for _, rpc := range [][]stats.RPCStats{
+		{
+			&stats.Begin{IsServerStream: true},
+			&stats.InPayload{Length: 10},
+			&stats.OutPayload{Length: 10},
+			&stats.OutPayload{Length: 20},
+			&stats.OutPayload{Length: 30},
+			&stats.End{},
+		},
+		{
+			&stats.Begin{},
+			&stats.InPayload{Length: 10},
+			&stats.OutPayload{Length: 10},
+			&stats.End{},
+		},
+	} {
+		ctx := server.TagRPC(context.Background(), info)
+		for _, s := range rpc {
+			server.HandleRPC(ctx, s)
+		}
+	}
+
+	client := &ClientStatsHandler{}
+	ctx := client.TagRPC(context.Background(), info)
+	for _, s := range []stats.RPCStats{
+		&stats.Begin{Client: true},
+		&stats.OutPayload{Length: 10},
+		&stats.InPayload{Length: 10},
+		&stats.End{},
+	} {
+		client.HandleRPC(ctx, s)
+	}
+
+	for _, tc := range []struct {
+		v              *istats.View
+		count          int64
+		min, max, mean float64
+	}{
+		{RPCServerStreamRequestCountView, 1, 1, 1, 1},
+		{RPCServerStreamResponseCountView, 1, 3, 3, 3},
+		{RPCServerStreamRequestBytesView, 1, 10, 10, 10},
+		{RPCServerStreamResponseBytesView, 1, 60, 60, 60},
+		{RPCServerTimeToFirstResponseView, 2, -1, -1, -1},
+		{RPCClientStreamRequestCountView, 0, 0, 0, 0},
+		{RPCClientTimeToFirstResponseView, 1, -1, -1, -1},
+	} {
+		rows, err := tc.v.RetrieveData()
+		if err != nil {
+			t.Fatalf("%v: RetrieveData = %v", tc.v.Name(), err)
+		}
+		if tc.count == 0 {
+			if len(rows) != 0 {
+				t.Errorf("%v: got rows %v, want none", tc.v.Name(), rows)
+			}
+			continue
+		}
+		if len(rows) != 1 {
+			t.Errorf("%v: got rows %v, want one row", tc.v.Name(), rows)
+			continue
+		}
+		d := rows[0].Data.(*istats.DistributionData)
+		if d.Count != tc.count {
+			t.Errorf("%v: got count %v, want %v", tc.v.Name(), d.Count, tc.count)
+		}
+		if tc.mean >= 0 && (d.Min != tc.min || d.Max != tc.max || d.Mean != tc.mean) {
+			t.Errorf("%v: got min, max, mean %v, %v, %v; want %v, %v, %v", tc.v.Name(), d.Min, d.Max, d.Mean, tc.min, tc.max, tc.mean)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package grpctrace
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"go.opencensus.io/trace"
 	"go.opencensus.io/trace/propagation"
@@ -30,10 +31,40 @@ import (
 // that can be passed to grpc.Dial
 // using grpc.WithStatsHandler to enable trace context propagation and
 // automatic span creation for outgoing gRPC requests.
-type ClientStatsHandler struct{}
+type ClientStatsHandler struct {
+	o ClientOptions
+}
 
 var _ stats.Handler = &ClientStatsHandler{}
 
+// ClientOptions contains options for a ClientStatsHandler.
+type ClientOptions struct {
+	// ConnectionSpans causes a span to be created for each connection,
+	// starting when the connection is established and ending when it is
+	// closed.
+	ConnectionSpans bool
+
+	// Status, if set, maps the error of a failed RPC to the status of
+	// its span. By default, the gRPC status code and description of the
+	// error are used.
+	Status func(err error) trace.Status
+
+	// SpanName, if set, returns the name of the span of the RPC with the
+	// given full method name, such as "/package.Service/Method". By
+	// default, the name is the full method name with slashes replaced by
+	// dots, prefixed by "Sent".
+	SpanName func(fullMethodName string) string
+
+	// Sampler, if set, returns the sampler of the spans of the RPCs of
+	// the given full method name. If it is nil or returns nil, the
+	// default sampler is used.
+	Sampler func(fullMethodName string) trace.Sampler
+
+	// Skip, if set, reports whether the RPCs of the given full method
+	// name are not to be traced. See SkipHealthAndReflection.
+	Skip func(fullMethodName string) bool
+}
+
 // NewClientStatsHandler returns a StatsHandler that can be passed to grpc.Dial
 // using grpc.WithStatsHandler to enable trace context propagation and
 // automatic span creation for outgoing gRPC requests.
@@ -41,6 +72,12 @@ func NewClientStatsHandler() *ClientStatsHandler {
 	return &ClientStatsHandler{}
 }
 
+// NewClientStatsHandlerWithOptions is like NewClientStatsHandler but
+// configures the handler with o.
+func NewClientStatsHandlerWithOptions(o ClientOptions) *ClientStatsHandler {
+	return &ClientStatsHandler{o: o}
+}
+
 // TODO(jbd): Remove NewClientStatsHandler and NewServerStatsHandler
 // given they are not doing anything than returning a zero value pointer.
 
@@ -48,7 +85,37 @@ func NewClientStatsHandler() *ClientStatsHandler {
 // that can be passed to grpc.NewServer using grpc.StatsHandler
 // to enable trace context propagation and automatic span creation
 // for incoming gRPC requests..
-type ServerStatsHandler struct{}
+type ServerStatsHandler struct {
+	o ServerOptions
+}
+
+// ServerOptions contains options for a ServerStatsHandler.
+type ServerOptions struct {
+	// ConnectionSpans causes a span to be created for each connection,
+	// starting when the connection is accepted and ending when it is
+	// closed. Connection spans are not the parents of RPC spans.
+	ConnectionSpans bool
+
+	// Status, if set, maps the error of a failed RPC to the status of
+	// its span. By default, the gRPC status code and description of the
+	// error are used.
+	Status func(err error) trace.Status
+
+	// SpanName, if set, returns the name of the span of the RPC with the
+	// given full method name, such as "/package.Service/Method". By
+	// default, the name is the full method name with slashes replaced by
+	// dots, prefixed by "Recv".
+	SpanName func(fullMethodName string) string
+
+	// Sampler, if set, returns the sampler of the spans of the RPCs of
+	// the given full method name. If it is nil or returns nil, the
+	// default sampler is used.
+	Sampler func(fullMethodName string) trace.Sampler
+
+	// Skip, if set, reports whether the RPCs of the given full method
+	// name are not to be traced. See SkipHealthAndReflection.
+	Skip func(fullMethodName string) bool
+}
 
 // NewServerStatsHandler returns a StatsHandler that can be passed to
 // grpc.NewServer using grpc.StatsHandler to enable trace context propagation
@@ -57,17 +124,99 @@ func NewServerStatsHandler() *ServerStatsHandler {
 	return &ServerStatsHandler{}
 }
 
+// NewServerStatsHandlerWithOptions is like NewServerStatsHandler but
+// configures the handler with o.
+func NewServerStatsHandlerWithOptions(o ServerOptions) *ServerStatsHandler {
+	return &ServerStatsHandler{o: o}
+}
+
 var _ stats.Handler = &ServerStatsHandler{}
 
 const traceContextKey = "grpc-trace-bin"
 
+// DebugKey is the gRPC metadata key that, when set to "1" or "true" in an
+// incoming request, forces the request's trace to be sampled by this server
+// and by the servers the trace is propagated to.
+const DebugKey = "grpc-trace-debug"
+
+// rpcData holds the message sequence numbers of an RPC.
+type rpcData struct {
+	sent, recv int64 // accessed atomically
+
+	// skip is set if the RPC is not traced.
+	skip bool
+}
+
+type rpcDataKey struct{}
+
+// withRPCData returns ctx with new rpcData attached.
+func withRPCData(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rpcDataKey{}, &rpcData{})
+}
+
+// withSkip returns ctx with the rpcData of an RPC that is not traced
+// attached.
+func withSkip(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rpcDataKey{}, &rpcData{skip: true})
+}
+
+// SkipHealthAndReflection reports whether fullMethodName is a method of the
+// gRPC health checking or server reflection services. It can be used as the
+// Skip option of the stats handlers.
+func SkipHealthAndReflection(fullMethodName string) bool {
+	return strings.HasPrefix(fullMethodName, "/grpc.health.") ||
+		strings.HasPrefix(fullMethodName, "/grpc.reflection.")
+}
+
+// spanName returns the name of the span of an RPC of the given method,
+// using format if it is non-nil or prefix otherwise.
+func spanName(format func(string) string, prefix, fullMethodName string) string {
+	if format != nil {
+		return format(fullMethodName)
+	}
+	return prefix + strings.Replace(fullMethodName, "/", ".", -1)
+}
+
+// startOptions returns the options of the span of an RPC of the given
+// method.
+func startOptions(sampler func(string) trace.Sampler, fullMethodName string) trace.StartSpanOptions {
+	opt := trace.StartSpanOptions{RecordEvents: true, RegisterNameForLocalSpanStore: true}
+	if sampler != nil {
+		opt.Sampler = sampler(fullMethodName)
+	}
+	return opt
+}
+
+// nextSent returns the ID of the next message sent, starting at 1,
+// or 0 if d is nil.
+func (d *rpcData) nextSent() int64 {
+	if d == nil {
+		return 0
+	}
+	return atomic.AddInt64(&d.sent, 1)
+}
+
+// nextRecv returns the ID of the next message received, starting at 1,
+// or 0 if d is nil.
+func (d *rpcData) nextRecv() int64 {
+	if d == nil {
+		return 0
+	}
+	return atomic.AddInt64(&d.recv, 1)
+}
+
 // TagRPC creates a new trace span for the client side of the RPC.
 //
 // It returns ctx with the new trace span added and a serialization of the
-// SpanContext added to the outgoing gRPC metadata.
+// SpanContext added to the outgoing gRPC metadata. If the method is
+// skipped, no span is created and the SpanContext is not propagated.
 func (c *ClientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	name := "Sent" + strings.Replace(rti.FullMethodName, "/", ".", -1)
-	ctx = trace.StartSpanWithOptions(ctx, name, trace.StartSpanOptions{RecordEvents: true, RegisterNameForLocalSpanStore: true})
+	if c.o.Skip != nil && c.o.Skip(rti.FullMethodName) {
+		return withSkip(ctx)
+	}
+	name := spanName(c.o.SpanName, "Sent", rti.FullMethodName)
+	ctx = trace.StartSpanWithOptions(ctx, name, startOptions(c.o.Sampler, rti.FullMethodName))
+	ctx = withRPCData(ctx)
 	traceContextBinary := propagation.Binary(trace.FromContext(ctx).SpanContext())
 	if len(traceContextBinary) == 0 {
 		return ctx
@@ -83,12 +232,21 @@ func (c *ClientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo)
 //
 // It checks the incoming gRPC metadata in ctx for a SpanContext, and if
 // it finds one, uses that SpanContext as the parent context of the new span.
+// If the metadata has DebugKey set, the span is forced to be sampled.
 //
-// It returns ctx, with the new trace span added.
+// It returns ctx, with the new trace span added. If the method is skipped,
+// no span is created.
 func (s *ServerStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
+	if s.o.Skip != nil && s.o.Skip(rti.FullMethodName) {
+		return withSkip(ctx)
+	}
+	ctx = withRPCData(ctx)
 	md, _ := metadata.FromIncomingContext(ctx)
-	name := "Recv" + strings.Replace(rti.FullMethodName, "/", ".", -1)
-	opt := trace.StartSpanOptions{RecordEvents: true, RegisterNameForLocalSpanStore: true}
+	name := spanName(s.o.SpanName, "Recv", rti.FullMethodName)
+	opt := startOptions(s.o.Sampler, rti.FullMethodName)
+	if d := md[DebugKey]; len(d) > 0 && (d[0] == "1" || d[0] == "true") {
+		opt.Debug = true
+	}
 	if s := md[traceContextKey]; len(s) > 0 {
 		if parent, ok := propagation.FromBinary([]byte(s[0])); ok {
 			return trace.StartSpanWithRemoteParent(ctx, name, parent, opt)
@@ -99,56 +257,112 @@ func (s *ServerStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo)
 
 // HandleRPC processes the RPC stats, adding information to the current trace span.
 func (c *ClientStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	handleRPC(ctx, rs)
+	handleRPC(ctx, rs, c.o.Status)
 }
 
 // HandleRPC processes the RPC stats, adding information to the current trace span.
 func (s *ServerStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	handleRPC(ctx, rs)
+	handleRPC(ctx, rs, s.o.Status)
 }
 
-func handleRPC(ctx context.Context, rs stats.RPCStats) {
+// handleRPC adds information to the span in ctx. The message events of
+// payloads are numbered from 1 in each direction, so that the IDs of the
+// events of a message on the client and on the server match; the events of
+// headers and trailers have ID 0. If status is non-nil, it maps the error
+// of a failed RPC to the status of the span.
+func handleRPC(ctx context.Context, rs stats.RPCStats, status func(error) trace.Status) {
 	// TODO: compressed and uncompressed sizes are not populated in every message.
+	d, _ := ctx.Value(rpcDataKey{}).(*rpcData)
+	if d != nil && d.skip {
+		return
+	}
 	switch rs := rs.(type) {
 	case *stats.Begin:
 		trace.SetSpanAttributes(ctx,
 			trace.BoolAttribute{Key: "Client", Value: rs.Client},
-			trace.BoolAttribute{Key: "FailFast", Value: rs.FailFast})
+			trace.BoolAttribute{Key: "FailFast", Value: rs.FailFast},
+			trace.BoolAttribute{Key: "ClientStream", Value: rs.IsClientStream},
+			trace.BoolAttribute{Key: "ServerStream", Value: rs.IsServerStream})
 	case *stats.InPayload:
-		trace.AddMessageReceiveEvent(ctx, 0 /* TODO: messageID */, int64(rs.Length), int64(rs.WireLength))
+		trace.AddMessageReceiveEvent(ctx, d.nextRecv(), int64(rs.Length), int64(rs.WireLength))
 	case *stats.InHeader:
 		trace.AddMessageReceiveEvent(ctx, 0, int64(rs.WireLength), int64(rs.WireLength))
 	case *stats.InTrailer:
 		trace.AddMessageReceiveEvent(ctx, 0, int64(rs.WireLength), int64(rs.WireLength))
 	case *stats.OutPayload:
-		trace.AddMessageSendEvent(ctx, 0, int64(rs.Length), int64(rs.WireLength))
+		trace.AddMessageSendEvent(ctx, d.nextSent(), int64(rs.Length), int64(rs.WireLength))
 	case *stats.OutHeader:
 		trace.AddMessageSendEvent(ctx, 0, 0, 0)
 	case *stats.OutTrailer:
 		trace.AddMessageSendEvent(ctx, 0, int64(rs.WireLength), int64(rs.WireLength))
 	case *stats.End:
 		if rs.Error != nil {
-			code, desc := grpc.Code(rs.Error), grpc.ErrorDesc(rs.Error)
-			trace.SetSpanStatus(ctx, trace.Status{Code: int32(code), Message: desc})
+			if status != nil {
+				trace.SetSpanStatus(ctx, status(rs.Error))
+			} else {
+				code, desc := grpc.Code(rs.Error), grpc.ErrorDesc(rs.Error)
+				trace.SetSpanStatus(ctx, trace.Status{Code: int32(code), Message: desc})
+			}
 		}
 		trace.EndSpan(ctx)
 	}
 }
 
-// TagConn is a no-op for this StatsHandler.
+// connSpanKey is the context key of the span of a connection. The span is
+// not stored with trace.WithSpan because the contexts of the RPCs of a
+// server connection are derived from the connection's context, and RPC
+// spans should not be children of the connection span.
+type connSpanKey struct{}
+
+// tagConn starts a span named name for the connection described by cti if
+// enabled is set, and returns ctx with the span added.
+func tagConn(ctx context.Context, enabled bool, name string, cti *stats.ConnTagInfo) context.Context {
+	if !enabled || cti == nil {
+		return ctx
+	}
+	span := trace.NewSpan(name, trace.StartSpanOptions{RecordEvents: true, RegisterNameForLocalSpanStore: true})
+	var attrs []trace.Attribute
+	if cti.RemoteAddr != nil {
+		attrs = append(attrs, trace.StringAttribute{Key: "RemoteAddr", Value: cti.RemoteAddr.String()})
+	}
+	if cti.LocalAddr != nil {
+		attrs = append(attrs, trace.StringAttribute{Key: "LocalAddr", Value: cti.LocalAddr.String()})
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, connSpanKey{}, span)
+}
+
+// handleConn ends the connection span in ctx, if any, when the connection
+// ends.
+func handleConn(ctx context.Context, cs stats.ConnStats) {
+	span, ok := ctx.Value(connSpanKey{}).(*trace.Span)
+	if !ok {
+		return
+	}
+	switch cs.(type) {
+	case *stats.ConnBegin:
+		span.Print("Connection established")
+	case *stats.ConnEnd:
+		span.End()
+	}
+}
+
+// TagConn starts a connection span if ConnectionSpans is set.
 func (c *ClientStatsHandler) TagConn(ctx context.Context, cti *stats.ConnTagInfo) context.Context {
-	return ctx
+	return tagConn(ctx, c.o.ConnectionSpans, "Sent.grpc.Conn", cti)
 }
 
-// TagConn is a no-op for this StatsHandler.
+// TagConn starts a connection span if ConnectionSpans is set.
 func (s *ServerStatsHandler) TagConn(ctx context.Context, cti *stats.ConnTagInfo) context.Context {
-	return ctx
+	return tagConn(ctx, s.o.ConnectionSpans, "Recv.grpc.Conn", cti)
 }
 
-// HandleConn is a no-op for this StatsHandler.
+// HandleConn ends the connection span, if any, when the connection ends.
 func (c *ClientStatsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	handleConn(ctx, cs)
 }
 
-// HandleConn is a no-op for this StatsHandler.
+// HandleConn ends the connection span, if any, when the connection ends.
 func (s *ServerStatsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	handleConn(ctx, cs)
 }
@@ -26,6 +26,8 @@ import (
 	"go.opencensus.io/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 )
 
 type testServer struct{}
@@ -197,3 +199,144 @@ func TestSpanCreation(t *testing.T) {
 	case <-time.After(time.Second / 10):
 	}
 }
+
+func TestServerDebugKey(t *testing.T) {
+	h := grpctrace.NewServerStatsHandler()
+	for _, test := range []struct {
+		md   metadata.MD
+		want bool
+	}{
+		{metadata.Pairs(), false},
+		{metadata.Pairs(grpctrace.DebugKey, "0"), false},
+		{metadata.Pairs(grpctrace.DebugKey, "1"), true},
+		{metadata.Pairs(grpctrace.DebugKey, "true"), true},
+	} {
+		ctx := metadata.NewIncomingContext(context.Background(), test.md)
+		ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/foo.Foo/Single"})
+		sc, _ := trace.SpanContextFromContext(ctx)
+		if got := sc.IsDebug() && sc.IsSampled(); got != test.want {
+			t.Errorf("%v: got sampled for debugging %t, want %t", test.md, got, test.want)
+		}
+	}
+}
+
+func TestMessageIDs(t *testing.T) {
+	te := &testExporter{ch: make(chan *trace.SpanData, 1)}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+
+	h := grpctrace.NewClientStatsHandler()
+	ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+	ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/foo.Foo/Multiple"})
+	for _, rs := range []stats.RPCStats{
+		&stats.OutHeader{},
+		&stats.OutPayload{Length: 1},
+		&stats.InPayload{Length: 2},
+		&stats.OutPayload{Length: 3},
+		&stats.InPayload{Length: 4},
+		&stats.InTrailer{},
+		&stats.End{},
+	} {
+		h.HandleRPC(ctx, rs)
+	}
+
+	sd := <-te.ch
+	var got []int64
+	for _, e := range sd.MessageEvents {
+		got = append(got, e.MessageID)
+	}
+	if want := []int64{0, 1, 1, 2, 2, 0}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got message IDs %v, want %v", got, want)
+	}
+}
+
+func TestConnectionSpans(t *testing.T) {
+	te := &testExporter{ch: make(chan *trace.SpanData, 1)}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+	trace.SetDefaultSampler(trace.AlwaysSample())
+	defer trace.SetDefaultSampler(trace.ProbabilitySampler(1e-4))
+
+	cti := &stats.ConnTagInfo{
+		RemoteAddr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 443},
+		LocalAddr:  &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 5000},
+	}
+	for _, test := range []struct {
+		h    stats.Handler
+		want string
+	}{
+		{grpctrace.NewClientStatsHandler(), ""},
+		{grpctrace.NewClientStatsHandlerWithOptions(grpctrace.ClientOptions{ConnectionSpans: true}), "Sent.grpc.Conn"},
+		{grpctrace.NewServerStatsHandlerWithOptions(grpctrace.ServerOptions{ConnectionSpans: true}), "Recv.grpc.Conn"},
+	} {
+		ctx := test.h.TagConn(context.Background(), cti)
+		test.h.HandleConn(ctx, &stats.ConnBegin{})
+		if trace.FromContext(ctx) != nil {
+			t.Errorf("%T: connection span is in the context", test.h)
+		}
+		test.h.HandleConn(ctx, &stats.ConnEnd{})
+		trace.Flush()
+
+		var got string
+		select {
+		case sd := <-te.ch:
+			got = sd.Name
+			if sd.Attributes["RemoteAddr"] != "10.0.0.1:443" || sd.Attributes["LocalAddr"] != "10.0.0.2:5000" {
+				t.Errorf("%v: got attributes %v", got, sd.Attributes)
+			}
+		case <-time.After(100 * time.Millisecond):
+		}
+		if got != test.want {
+			t.Errorf("%T: got span %q, want %q", test.h, got, test.want)
+		}
+	}
+}
+
+func TestHandlerOptions(t *testing.T) {
+	te := &testExporter{ch: make(chan *trace.SpanData, 1)}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+
+	spanName := func(fullMethodName string) string { return "rpc" + fullMethodName }
+	sampler := func(fullMethodName string) trace.Sampler {
+		if fullMethodName == "/foo.Foo/Single" {
+			return trace.AlwaysSample()
+		}
+		return nil
+	}
+	handlers := []stats.Handler{
+		grpctrace.NewClientStatsHandlerWithOptions(grpctrace.ClientOptions{SpanName: spanName, Sampler: sampler, Skip: grpctrace.SkipHealthAndReflection}),
+		grpctrace.NewServerStatsHandlerWithOptions(grpctrace.ServerOptions{SpanName: spanName, Sampler: sampler, Skip: grpctrace.SkipHealthAndReflection}),
+	}
+	for _, h := range handlers {
+		for _, test := range []struct {
+			method string
+			want   string
+		}{
+			{"/foo.Foo/Single", "rpc/foo.Foo/Single"},
+			{"/foo.Foo/Multiple", ""}, // not sampled by default
+			{"/grpc.health.v1.Health/Check", ""},
+			{"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", ""},
+		} {
+			parent := trace.NewSpan("parent", trace.StartSpanOptions{Sampler: trace.NeverSample()})
+			ctx := trace.WithSpan(context.Background(), parent)
+			ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: test.method})
+			h.HandleRPC(ctx, &stats.End{})
+			trace.Flush()
+
+			var got string
+			select {
+			case sd := <-te.ch:
+				got = sd.Name
+			case <-time.After(100 * time.Millisecond):
+			}
+			if got != test.want {
+				t.Errorf("%T: %v: got span %q, want %q", h, test.method, got, test.want)
+			}
+			newSpan := trace.FromContext(ctx) != parent
+			if want := !grpctrace.SkipHealthAndReflection(test.method); newSpan != want {
+				t.Errorf("%T: %v: got new span %t, want %t", h, test.method, newSpan, want)
+			}
+		}
+	}
+}
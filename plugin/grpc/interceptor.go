@@ -0,0 +1,301 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"go.opencensus.io/plugin/grpc/grpcstats"
+	"go.opencensus.io/plugin/grpc/grpctrace"
+	"go.opencensus.io/trace"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/stats"
+)
+
+// InterceptorOptions contains options for the interceptors.
+type InterceptorOptions struct {
+	// RequestHook, if set, is called with the context of the RPC for each
+	// request message, after it is sent by a client or received by a
+	// server. It can be used to add attributes to the span of the RPC.
+	RequestHook func(ctx context.Context, req interface{})
+
+	// ResponseHook, if set, is called with the context of the RPC for
+	// each response message, after it is received by a client or sent by
+	// a server. It can be used to add attributes to the span of the RPC.
+	ResponseHook func(ctx context.Context, resp interface{})
+
+	// Status, if set, maps the error of a failed RPC to the status of
+	// its span. By default, the gRPC status code and description of the
+	// error are used. It is used when the Status of ClientTrace or
+	// ServerTrace is not set.
+	Status func(err error) trace.Status
+
+	// ClientStats and ServerStats configure the stats of the client and
+	// server interceptors, like grpcstats.NewClientStatsHandlerWithOptions
+	// and grpcstats.NewServerStatsHandlerWithOptions. Their Views are
+	// subscribed to when the interceptor is created; if they cannot be,
+	// the error is logged.
+	ClientStats grpcstats.ClientOptions
+	ServerStats grpcstats.ServerOptions
+
+	// ClientTrace and ServerTrace configure the spans of the client and
+	// server interceptors, like grpctrace.NewClientStatsHandlerWithOptions
+	// and grpctrace.NewServerStatsHandlerWithOptions.
+	ClientTrace grpctrace.ClientOptions
+	ServerTrace grpctrace.ServerOptions
+}
+
+func (o *InterceptorOptions) request(ctx context.Context, req interface{}) {
+	if o.RequestHook != nil {
+		o.RequestHook(ctx, req)
+	}
+}
+
+func (o *InterceptorOptions) response(ctx context.Context, resp interface{}) {
+	if o.ResponseHook != nil {
+		o.ResponseHook(ctx, resp)
+	}
+}
+
+// UnaryClientInterceptor returns an interceptor that can be passed to
+// grpc.Dial using grpc.WithUnaryInterceptor as an alternative to
+// NewClientStatsHandler. It enables OpenCensus stats and trace for unary
+// RPCs, producing the same spans and measures as NewClientStatsHandler.
+func UnaryClientInterceptor(o InterceptorOptions) grpc.UnaryClientInterceptor {
+	h := newClientHandler(o)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: method})
+		h.HandleRPC(ctx, &stats.Begin{Client: true, BeginTime: time.Now()})
+		h.HandleRPC(ctx, outPayload(true, req))
+		o.request(ctx, req)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			h.HandleRPC(ctx, inPayload(true, reply))
+			o.response(ctx, reply)
+		}
+		h.HandleRPC(ctx, &stats.End{Client: true, EndTime: time.Now(), Error: err})
+		return err
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that can be passed to
+// grpc.Dial using grpc.WithStreamInterceptor as an alternative to
+// NewClientStatsHandler. It enables OpenCensus stats and trace for
+// streaming RPCs, producing the same spans and measures as
+// NewClientStatsHandler.
+//
+// The RPC ends when RecvMsg returns an error, including io.EOF, or, for
+// RPCs without a server stream, when RecvMsg receives the response, so
+// the stream must be read until then for the span to be ended and the
+// measures to be recorded.
+func StreamClientInterceptor(o InterceptorOptions) grpc.StreamClientInterceptor {
+	h := newClientHandler(o)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: method})
+		h.HandleRPC(ctx, &stats.Begin{
+			Client:         true,
+			BeginTime:      time.Now(),
+			IsClientStream: desc.ClientStreams,
+			IsServerStream: desc.ServerStreams,
+		})
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			h.HandleRPC(ctx, &stats.End{Client: true, EndTime: time.Now(), Error: err})
+			return nil, err
+		}
+		return &clientStream{ClientStream: s, desc: desc, ctx: ctx, h: h, o: o}, nil
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that can be passed to
+// grpc.NewServer using grpc.UnaryInterceptor as an alternative to
+// NewServerStatsHandler. It enables OpenCensus stats and trace for unary
+// RPCs, producing the same spans and measures as NewServerStatsHandler.
+func UnaryServerInterceptor(o InterceptorOptions) grpc.UnaryServerInterceptor {
+	h := newServerHandler(o)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: info.FullMethod})
+		h.HandleRPC(ctx, &stats.Begin{BeginTime: time.Now()})
+		h.HandleRPC(ctx, inPayload(false, req))
+		o.request(ctx, req)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			h.HandleRPC(ctx, outPayload(false, resp))
+			o.response(ctx, resp)
+		}
+		h.HandleRPC(ctx, &stats.End{EndTime: time.Now(), Error: err})
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that can be passed to
+// grpc.NewServer using grpc.StreamInterceptor as an alternative to
+// NewServerStatsHandler. It enables OpenCensus stats and trace for
+// streaming RPCs, producing the same spans and measures as
+// NewServerStatsHandler.
+func StreamServerInterceptor(o InterceptorOptions) grpc.StreamServerInterceptor {
+	h := newServerHandler(o)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := h.TagRPC(ss.Context(), &stats.RPCTagInfo{FullMethodName: info.FullMethod})
+		h.HandleRPC(ctx, &stats.Begin{
+			BeginTime:      time.Now(),
+			IsClientStream: info.IsClientStream,
+			IsServerStream: info.IsServerStream,
+		})
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx, h: h, o: o})
+		h.HandleRPC(ctx, &stats.End{EndTime: time.Now(), Error: err})
+		return err
+	}
+}
+
+func newClientHandler(o InterceptorOptions) handler {
+	sh, err := grpcstats.NewClientStatsHandlerWithOptions(o.ClientStats)
+	if err != nil {
+		grpclog.Errorf("Cannot subscribe the views of the client interceptor: %v", err)
+		so := o.ClientStats
+		so.Views = nil
+		sh, _ = grpcstats.NewClientStatsHandlerWithOptions(so)
+	}
+	to := o.ClientTrace
+	if to.Status == nil {
+		to.Status = o.Status
+	}
+	return handler{sh, grpctrace.NewClientStatsHandlerWithOptions(to)}
+}
+
+func newServerHandler(o InterceptorOptions) handler {
+	sh, err := grpcstats.NewServerStatsHandlerWithOptions(o.ServerStats)
+	if err != nil {
+		grpclog.Errorf("Cannot subscribe the views of the server interceptor: %v", err)
+		so := o.ServerStats
+		so.Views = nil
+		sh, _ = grpcstats.NewServerStatsHandlerWithOptions(so)
+	}
+	to := o.ServerTrace
+	if to.Status == nil {
+		to.Status = o.Status
+	}
+	return handler{sh, grpctrace.NewServerStatsHandlerWithOptions(to)}
+}
+
+// clientStream reports the messages of a client stream to h.
+type clientStream struct {
+	grpc.ClientStream
+	desc *grpc.StreamDesc
+	ctx  context.Context
+	h    handler
+	o    InterceptorOptions
+	once sync.Once
+}
+
+func (s *clientStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		if err != io.EOF {
+			s.end(err)
+		}
+		return err
+	}
+	s.h.HandleRPC(s.ctx, outPayload(true, m))
+	s.o.request(s.ctx, m)
+	return nil
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.end(nil)
+		return err
+	}
+	if err != nil {
+		s.end(err)
+		return err
+	}
+	s.h.HandleRPC(s.ctx, inPayload(true, m))
+	s.o.response(s.ctx, m)
+	if !s.desc.ServerStreams {
+		// The only response was received; grpc finishes the RPC
+		// without RecvMsg returning io.EOF.
+		s.end(nil)
+	}
+	return nil
+}
+
+// end reports the end of the stream to h once.
+func (s *clientStream) end(err error) {
+	s.once.Do(func() {
+		s.h.HandleRPC(s.ctx, &stats.End{Client: true, EndTime: time.Now(), Error: err})
+	})
+}
+
+// serverStream reports the messages of a server stream to h.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	h   handler
+	o   InterceptorOptions
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.h.HandleRPC(s.ctx, outPayload(false, m))
+	s.o.response(s.ctx, m)
+	return nil
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.h.HandleRPC(s.ctx, inPayload(false, m))
+	s.o.request(s.ctx, m)
+	return nil
+}
+
+func inPayload(client bool, m interface{}) *stats.InPayload {
+	n := messageSize(m)
+	return &stats.InPayload{Client: client, Payload: m, Length: n, WireLength: n, RecvTime: time.Now()}
+}
+
+func outPayload(client bool, m interface{}) *stats.OutPayload {
+	n := messageSize(m)
+	return &stats.OutPayload{Client: client, Payload: m, Length: n, WireLength: n, SentTime: time.Now()}
+}
+
+// messageSize returns the encoded size of m if it is a protocol buffer
+// message, or 0.
+func messageSize(m interface{}) int {
+	if pm, ok := m.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
@@ -0,0 +1,327 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"io"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"go.opencensus.io/plugin/grpc/grpcstats"
+	"go.opencensus.io/plugin/grpc/grpctrace"
+	istats "go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var testInterceptorOptions = InterceptorOptions{
+	RequestHook: func(ctx context.Context, req interface{}) {
+		trace.SetSpanAttributes(ctx, trace.StringAttribute{Key: "req", Value: req.(string)})
+	},
+	ResponseHook: func(ctx context.Context, resp interface{}) {
+		trace.SetSpanAttributes(ctx, trace.StringAttribute{Key: "resp", Value: resp.(string)})
+	},
+	Status: func(err error) trace.Status {
+		return trace.Status{Code: 100 + int32(grpc.Code(err)), Message: "mapped"}
+	},
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	te := &traceExporter{}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+	for _, v := range []*istats.View{grpcstats.RPCClientRequestCountView, grpcstats.RPCServerRequestCountView} {
+		if err := v.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		defer v.Unsubscribe()
+	}
+
+	client := UnaryClientInterceptor(testInterceptorOptions)
+	server := UnaryServerInterceptor(testInterceptorOptions)
+	const method = "/service.foo/method"
+	for _, test := range []struct {
+		err      error
+		wantResp bool
+		wantCode int32
+	}{
+		{nil, true, 0},
+		{grpc.Errorf(codes.NotFound, "not found"), false, 105},
+	} {
+		te.buffer = nil
+		ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return test.err
+		}
+		if err := client(ctx, method, "request", "response", nil, invoker); err != test.err {
+			t.Errorf("client interceptor returned %v, want %v", err, test.err)
+		}
+
+		// The server span is sampled because its parent is, as if the
+		// trace context was propagated by the client.
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			if test.err != nil {
+				return nil, test.err
+			}
+			return "response", nil
+		}
+		if _, err := server(ctx, "request", &grpc.UnaryServerInfo{FullMethod: method}, handler); err != test.err {
+			t.Errorf("server interceptor returned %v, want %v", err, test.err)
+		}
+		trace.Flush()
+
+		if got, want := len(te.buffer), 2; got != want {
+			t.Fatalf("%v: got %d spans, want %d", test.err, got, want)
+		}
+		for i, name := range []string{"Sent.service.foo.method", "Recv.service.foo.method"} {
+			sd := te.buffer[i]
+			if sd.Name != name {
+				t.Errorf("%v: got span %q, want %q", test.err, sd.Name, name)
+			}
+			if got := sd.Attributes["req"]; got != "request" {
+				t.Errorf("%v: %v: got req attribute %v, want request", test.err, name, got)
+			}
+			if _, got := sd.Attributes["resp"]; got != test.wantResp {
+				t.Errorf("%v: %v: got resp attribute %t, want %t", test.err, name, got, test.wantResp)
+			}
+			if sd.Status.Code != test.wantCode {
+				t.Errorf("%v: %v: got status %v, want code %d", test.err, name, sd.Status, test.wantCode)
+			}
+		}
+	}
+
+	for _, v := range []*istats.View{grpcstats.RPCClientRequestCountView, grpcstats.RPCServerRequestCountView} {
+		rows, err := v.RetrieveData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) == 0 {
+			t.Errorf("%v: got no rows", v.Name())
+		}
+	}
+}
+
+func TestInterceptorOptions(t *testing.T) {
+	te := &traceExporter{}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+
+	o := InterceptorOptions{
+		ClientStats: grpcstats.ClientOptions{DisableTagPropagation: true},
+		ClientTrace: grpctrace.ClientOptions{Skip: grpctrace.SkipHealthAndReflection},
+	}
+	k, _ := tag.NewKey("k")
+	tm, err := tag.NewMap(context.Background(), tag.Insert(k, "v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := tag.NewContext(context.Background(), tm)
+	ctx = trace.StartSpanWithOptions(ctx, "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+	var propagated bool
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		propagated = len(md["grpc-tags-bin"]) > 0
+		return nil
+	}
+	if err := UnaryClientInterceptor(o)(ctx, "/grpc.health.v1.Health/Check", "request", "response", nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	trace.Flush()
+
+	if propagated {
+		t.Error("tags were propagated with DisableTagPropagation")
+	}
+	if len(te.buffer) != 0 {
+		t.Errorf("got %d spans for a skipped method, want 0", len(te.buffer))
+	}
+}
+
+type testClientStream struct {
+	ctx     context.Context
+	recv    []string
+	recvErr error
+}
+
+func (s *testClientStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *testClientStream) Trailer() metadata.MD         { return nil }
+func (s *testClientStream) CloseSend() error             { return nil }
+func (s *testClientStream) Context() context.Context     { return s.ctx }
+func (s *testClientStream) SendMsg(m interface{}) error  { return nil }
+
+func (s *testClientStream) RecvMsg(m interface{}) error {
+	if len(s.recv) == 0 {
+		return s.recvErr
+	}
+	*m.(*string), s.recv = s.recv[0], s.recv[1:]
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	te := &traceExporter{}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+	v := grpcstats.RPCClientFinishedCountByStatusView
+	if err := v.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Unsubscribe()
+
+	streamErr := grpc.Errorf(codes.Unavailable, "unavailable")
+	recvErr := grpc.Errorf(codes.Internal, "internal")
+	client := StreamClientInterceptor(InterceptorOptions{})
+	for _, test := range []struct {
+		method    string
+		desc      grpc.StreamDesc
+		streamErr error
+		recv      []string
+		recvErr   error
+		wantRecv  int
+		wantCode  int32
+	}{
+		// Generated code calls RecvMsg once for client-streaming RPCs,
+		// which grpc finishes after receiving the response.
+		{method: "client_stream", desc: grpc.StreamDesc{ClientStreams: true}, recv: []string{"response"}, wantRecv: 1},
+		{method: "server_stream", desc: grpc.StreamDesc{ServerStreams: true}, recv: []string{"a", "b"}, recvErr: io.EOF, wantRecv: 3},
+		{method: "stream_error", desc: grpc.StreamDesc{ServerStreams: true}, streamErr: streamErr, wantCode: int32(codes.Unavailable)},
+		{method: "recv_error", desc: grpc.StreamDesc{ServerStreams: true}, recv: []string{"a"}, recvErr: recvErr, wantRecv: 2, wantCode: int32(codes.Internal)},
+	} {
+		te.buffer = nil
+		ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+		streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			if test.streamErr != nil {
+				return nil, test.streamErr
+			}
+			return &testClientStream{ctx: ctx, recv: test.recv, recvErr: test.recvErr}, nil
+		}
+		cs, err := client(ctx, &test.desc, nil, "/service.foo/"+test.method, streamer)
+		if err != test.streamErr {
+			t.Errorf("%s: got error %v, want %v", test.method, err, test.streamErr)
+		}
+		if err == nil {
+			if err := cs.SendMsg("request"); err != nil {
+				t.Errorf("%s: SendMsg() = %v", test.method, err)
+			}
+			for i := 0; i < test.wantRecv; i++ {
+				var m string
+				err := cs.RecvMsg(&m)
+				if last := i == test.wantRecv-1; last && err != test.recvErr || !last && err != nil {
+					t.Errorf("%s: RecvMsg() #%d = %v", test.method, i, err)
+				}
+			}
+		}
+		trace.Flush()
+
+		if len(te.buffer) != 1 {
+			t.Errorf("%s: got %d spans, want 1", test.method, len(te.buffer))
+		} else if got := te.buffer[0].Status.Code; got != test.wantCode {
+			t.Errorf("%s: got status code %d, want %d", test.method, got, test.wantCode)
+		}
+
+		rows, err := v.RetrieveData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var finished int64
+		for _, r := range rows {
+			for _, tag := range r.Tags {
+				if tag.Value == test.method {
+					finished += int64(*r.Data.(*istats.CountData))
+				}
+			}
+		}
+		if finished != 1 {
+			t.Errorf("%s: got %d finished RPCs recorded, want 1", test.method, finished)
+		}
+	}
+}
+
+type testServerStream struct {
+	ctx  context.Context
+	recv []string
+	sent []string
+}
+
+func (s *testServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *testServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *testServerStream) SetTrailer(metadata.MD)       {}
+func (s *testServerStream) Context() context.Context     { return s.ctx }
+
+func (s *testServerStream) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m.(string))
+	return nil
+}
+
+func (s *testServerStream) RecvMsg(m interface{}) error {
+	if len(s.recv) == 0 {
+		return io.EOF
+	}
+	*m.(*string), s.recv = s.recv[0], s.recv[1:]
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	te := &traceExporter{}
+	trace.RegisterExporter(te)
+	defer trace.UnregisterExporter(te)
+
+	var requests int
+	o := InterceptorOptions{
+		RequestHook: func(ctx context.Context, req interface{}) { requests++ },
+	}
+	ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+	ss := &testServerStream{ctx: ctx, recv: []string{"a", "b"}}
+	info := &grpc.StreamServerInfo{FullMethod: "/service.foo/stream", IsClientStream: true}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		if trace.FromContext(stream.Context()) == trace.FromContext(ctx) {
+			t.Error("stream context does not have the RPC span")
+		}
+		for {
+			var m string
+			if err := stream.RecvMsg(&m); err == io.EOF {
+				break
+			}
+		}
+		return stream.SendMsg("done")
+	}
+	if err := StreamServerInterceptor(o)(nil, ss, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	trace.Flush()
+
+	if requests != 2 {
+		t.Errorf("got %d request hook calls, want 2", requests)
+	}
+	if len(te.buffer) != 1 {
+		t.Fatalf("got %d spans, want 1", len(te.buffer))
+	}
+	var recv, sent int
+	for _, e := range te.buffer[0].MessageEvents {
+		switch e.EventType {
+		case trace.MessageEventTypeRecv:
+			recv++
+		case trace.MessageEventTypeSent:
+			sent++
+		}
+	}
+	if recv != 2 || sent != 1 {
+		t.Errorf("got %d received and %d sent message events, want 2 and 1", recv, sent)
+	}
+}
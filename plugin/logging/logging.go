@@ -0,0 +1,144 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging adds the trace and tags of a context to log lines,
+// so that logs can be joined with traces in a backend.
+package logging // import "go.opencensus.io/plugin/logging"
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	"golang.org/x/net/context"
+)
+
+// Field is a key value pair added to log lines.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// The keys of the fields describing the current span.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+	SampledKey = "sampled"
+)
+
+// Fields returns the fields describing the current span in ctx, if any,
+// followed by the values in ctx of the tags with the given keys, if set.
+func Fields(ctx context.Context, keys ...tag.Key) []Field {
+	return append(spanFields(ctx), tagFields(ctx, keys)...)
+}
+
+func spanFields(ctx context.Context) []Field {
+	sc, ok := trace.SpanContextFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []Field{
+		{TraceIDKey, sc.TraceID.String()},
+		{SpanIDKey, sc.SpanID.String()},
+		{SampledKey, strconv.FormatBool(sc.IsSampled())},
+	}
+}
+
+func tagFields(ctx context.Context, keys []tag.Key) []Field {
+	if len(keys) == 0 {
+		return nil
+	}
+	var fields []Field
+	m := tag.FromContext(ctx)
+	for _, k := range keys {
+		if v, ok := m.Value(k); ok {
+			fields = append(fields, Field{k.Name(), v})
+		}
+	}
+	return fields
+}
+
+// Options configures a Logger.
+type Options struct {
+	// TagKeys are the keys of the tags added to log lines.
+	TagKeys []tag.Key
+
+	// Annotate, if true, also adds each log line as an annotation
+	// to the current span, with the tags as attributes.
+	Annotate bool
+}
+
+// Logger is a context-aware wrapper of a log.Logger. Each line it logs
+// starts with the fields returned by Fields, formatted as key=value.
+type Logger struct {
+	l *log.Logger
+	o Options
+}
+
+// New returns a Logger writing to l. If l is nil, the Logger writes to
+// the standard error like the standard logger of the log package.
+func New(l *log.Logger, o Options) *Logger {
+	if l == nil {
+		l = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &Logger{l: l, o: o}
+}
+
+// Print logs the fields of ctx followed by its arguments,
+// which are handled in the manner of fmt.Print.
+func (l *Logger) Print(ctx context.Context, v ...interface{}) {
+	l.output(ctx, fmt.Sprint(v...))
+}
+
+// Printf logs the fields of ctx followed by its arguments,
+// which are handled in the manner of fmt.Printf.
+func (l *Logger) Printf(ctx context.Context, format string, v ...interface{}) {
+	l.output(ctx, fmt.Sprintf(format, v...))
+}
+
+// Println logs the fields of ctx followed by its arguments,
+// which are handled in the manner of fmt.Println.
+func (l *Logger) Println(ctx context.Context, v ...interface{}) {
+	l.output(ctx, fmt.Sprintln(v...))
+}
+
+func (l *Logger) output(ctx context.Context, msg string) {
+	tags := tagFields(ctx, l.o.TagKeys)
+	var buf bytes.Buffer
+	for _, f := range append(spanFields(ctx), tags...) {
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		if f.Value == "" || strings.ContainsAny(f.Value, " \t\n\"=") {
+			buf.WriteString(strconv.Quote(f.Value))
+		} else {
+			buf.WriteString(f.Value)
+		}
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(msg)
+	l.l.Output(3, buf.String())
+
+	if l.o.Annotate {
+		var attributes []trace.Attribute
+		for _, f := range tags {
+			attributes = append(attributes, trace.StringAttribute{Key: f.Key, Value: f.Value})
+		}
+		trace.PrintWithAttributes(ctx, attributes, strings.TrimSuffix(msg, "\n"))
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	"golang.org/x/net/context"
+)
+
+type testExporter struct {
+	spans []*trace.SpanData
+}
+
+func (e *testExporter) Export(sd *trace.SpanData) {
+	e.spans = append(e.spans, sd)
+}
+
+func TestLogger(t *testing.T) {
+	method, err := tag.NewKey("method")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := tag.NewKey("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := tag.NewMap(context.Background(), tag.Insert(method, "Get"), tag.Insert(user, "alice smith"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	l := New(log.New(&buf, "", 0), Options{TagKeys: []tag.Key{method, user}, Annotate: true})
+
+	l.Printf(tag.NewContext(context.Background(), tags), "no %s", "span")
+	if got, want := buf.String(), "method=Get user=\"alice smith\" no span\n"; got != want {
+		t.Errorf("without a span: got %q, want %q", got, want)
+	}
+	buf.Reset()
+
+	var te testExporter
+	trace.RegisterExporter(&te)
+	defer trace.UnregisterExporter(&te)
+	ctx := trace.StartSpanWithOptions(tag.NewContext(context.Background(), tags), "span", trace.StartSpanOptions{
+		Sampler: trace.AlwaysSample(),
+	})
+	sc, _ := trace.SpanContextFromContext(ctx)
+	l.Println(ctx, "with", "span")
+	trace.EndSpan(ctx)
+	trace.Flush()
+
+	want := fmt.Sprintf("trace_id=%s span_id=%s sampled=true method=Get user=\"alice smith\" with span\n", sc.TraceID, sc.SpanID)
+	if got := buf.String(); got != want {
+		t.Errorf("with a span: got %q, want %q", got, want)
+	}
+	if len(te.spans) != 1 || len(te.spans[0].Annotations) != 1 {
+		t.Fatalf("got exported spans %+v, want one span with one annotation", te.spans)
+	}
+	a := te.spans[0].Annotations[0]
+	wantAttributes := map[string]interface{}{"method": "Get", "user": "alice smith"}
+	if a.Message != "with span" || !reflect.DeepEqual(a.Attributes, wantAttributes) {
+		t.Errorf("got annotation %q with attributes %v, want %q with %v", a.Message, a.Attributes, "with span", wantAttributes)
+	}
+}
+
+func TestFields(t *testing.T) {
+	if got := Fields(context.Background()); len(got) != 0 {
+		t.Errorf("Fields(context.Background()) = %v, want no fields", got)
+	}
+	ctx := trace.StartSpanWithOptions(context.Background(), "span", trace.StartSpanOptions{Sampler: trace.NeverSample()})
+	sc, _ := trace.SpanContextFromContext(ctx)
+	want := []Field{
+		{TraceIDKey, sc.TraceID.String()},
+		{SpanIDKey, sc.SpanID.String()},
+		{SampledKey, "false"},
+	}
+	if got := Fields(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sql instruments database/sql drivers with OpenCensus.
+//
+// Wrapped drivers start a child span of the span in the context for each
+// Query, Exec, Prepare, Begin, Commit and Rollback call, with the sanitized
+// statement as the "sql.query" attribute, and record the CallCount, Latency
+// and ErrorCount measures.
+//
+// For example, to instrument a driver registered by a package:
+//
+//	sql.Register("instrumented-postgres", ocsql.Wrap(&pq.Driver{}))
+//	db, err := sql.Open("instrumented-postgres", dsn)
+//
+// or a driver.Connector:
+//
+//	db := sql.OpenDB(ocsql.WrapConnector(connector))
+package sql // import "go.opencensus.io/plugin/sql"
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+)
+
+// QueryAttribute is the key of the span attribute holding the sanitized
+// statement, in which string and numeric literals are replaced with "?".
+const QueryAttribute = "sql.query"
+
+// Wrap returns a driver.Driver instrumenting d.
+func Wrap(d driver.Driver) driver.Driver {
+	return wrappedDriver{parent: d}
+}
+
+// WrapConnector returns a driver.Connector instrumenting c.
+func WrapConnector(c driver.Connector) driver.Connector {
+	return wrappedConnector{parent: c}
+}
+
+type wrappedDriver struct {
+	parent driver.Driver
+}
+
+func (d wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{parent: c}, nil
+}
+
+type wrappedConnector struct {
+	parent driver.Connector
+}
+
+func (c wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{parent: cn}, nil
+}
+
+func (c wrappedConnector) Driver() driver.Driver {
+	return Wrap(c.parent.Driver())
+}
+
+// call is an instrumented call to a database method.
+type call struct {
+	ctx    context.Context
+	method string
+	start  time.Time
+}
+
+// startCall starts a span for method as a child of the span in ctx and
+// returns the context holding it. If query is not empty, it is sanitized
+// and added to the span.
+func startCall(ctx context.Context, method, query string) (context.Context, *call) {
+	ctx = trace.StartSpanWithOptions(ctx, "sql."+method, trace.StartSpanOptions{})
+	if query != "" {
+		trace.SetSpanAttributes(ctx, trace.StringAttribute{Key: QueryAttribute, Value: sanitize(query)})
+	}
+	return ctx, &call{ctx: ctx, method: method, start: time.Now()}
+}
+
+// end ends the call's span and records its measures.
+//
+// If err is driver.ErrSkip, the database/sql package falls back on
+// another method, which is instrumented instead: the span is discarded,
+// so it is not exported, and no measure is recorded.
+func (c *call) end(err error) {
+	if err == driver.ErrSkip {
+		trace.EndSpanWithOptions(c.ctx, trace.EndOptions{Discard: true})
+		return
+	}
+	ms := []stats.Measurement{
+		CallCount.M(1),
+		Latency.M(float64(time.Since(c.start)) / float64(time.Millisecond)),
+	}
+	if err != nil {
+		trace.SetSpanStatus(c.ctx, trace.Status{Code: 2 /* UNKNOWN */, Message: err.Error()})
+		ms = append(ms, ErrorCount.M(1))
+	}
+	trace.EndSpan(c.ctx)
+
+	ctx := c.ctx
+	if m, terr := tag.NewMap(ctx, tag.Upsert(MethodKey, c.method)); terr == nil {
+		ctx = tag.NewContext(ctx, m)
+	}
+	stats.Record(ctx, ms...)
+}
+
+// conn instruments a driver.Conn. It implements the optional interfaces
+// of database/sql/driver, falling back on the mandatory methods of the
+// wrapped connection, or returning driver.ErrSkip, when the wrapped
+// connection doesn't implement them.
+type conn struct {
+	parent driver.Conn
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	ctx, cl := startCall(ctx, "Prepare", query)
+	var s driver.Stmt
+	var err error
+	if p, ok := c.parent.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.parent.Prepare(query)
+	}
+	cl.end(err)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{parent: s, conn: c.parent, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return c.parent.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	txCtx := ctx
+	ctx, cl := startCall(ctx, "Begin", "")
+	var t driver.Tx
+	var err error
+	if b, ok := c.parent.(driver.ConnBeginTx); ok {
+		t, err = b.BeginTx(ctx, opts)
+	} else if opts.Isolation != 0 || opts.ReadOnly {
+		err = errors.New("sql: driver does not support non-default isolation levels or read-only transactions")
+	} else {
+		t, err = c.parent.Begin()
+	}
+	cl.end(err)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{parent: t, ctx: txCtx}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, eok := c.parent.(driver.ExecerContext)
+	le, leok := c.parent.(driver.Execer)
+	if !eok && !leok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cl := startCall(ctx, "Exec", query)
+	var r driver.Result
+	var err error
+	if eok {
+		r, err = e.ExecContext(ctx, query, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = values(args); err == nil {
+			r, err = le.Exec(query, vs)
+		}
+	}
+	cl.end(err)
+	return r, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, qok := c.parent.(driver.QueryerContext)
+	lq, lqok := c.parent.(driver.Queryer)
+	if !qok && !lqok {
+		return nil, driver.ErrSkip
+	}
+	ctx, cl := startCall(ctx, "Query", query)
+	var r driver.Rows
+	var err error
+	if qok {
+		r, err = q.QueryContext(ctx, query, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = values(args); err == nil {
+			r, err = lq.Query(query, vs)
+		}
+	}
+	cl.end(err)
+	return r, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.parent.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.parent.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// stmt instruments a driver.Stmt.
+type stmt struct {
+	parent driver.Stmt
+	conn   driver.Conn
+	query  string
+}
+
+var (
+	_ driver.Stmt              = (*stmt)(nil)
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+	_ driver.ColumnConverter   = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, cl := startCall(ctx, "Exec", s.query)
+	var r driver.Result
+	var err error
+	if e, ok := s.parent.(driver.StmtExecContext); ok {
+		r, err = e.ExecContext(ctx, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = values(args); err == nil {
+			r, err = s.parent.Exec(vs)
+		}
+	}
+	cl.end(err)
+	return r, err
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, cl := startCall(ctx, "Query", s.query)
+	var r driver.Rows
+	var err error
+	if q, ok := s.parent.(driver.StmtQueryContext); ok {
+		r, err = q.QueryContext(ctx, args)
+	} else {
+		var vs []driver.Value
+		if vs, err = values(args); err == nil {
+			r, err = s.parent.Query(vs)
+		}
+	}
+	cl.end(err)
+	return r, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.parent.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	if n, ok := s.conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter returns the converter of the wrapped statement for the
+// column idx, or the converter database/sql uses when the statement has
+// none.
+func (s *stmt) ColumnConverter(idx int) driver.ValueConverter {
+	if c, ok := s.parent.(driver.ColumnConverter); ok {
+		return c.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// tx instruments a driver.Tx. Its spans are children
+// of the span in the context the transaction began with.
+type tx struct {
+	parent driver.Tx
+	ctx    context.Context
+}
+
+func (t *tx) Commit() error {
+	_, cl := startCall(t.ctx, "Commit", "")
+	err := t.parent.Commit()
+	cl.end(err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, cl := startCall(t.ctx, "Rollback", "")
+	err := t.parent.Rollback()
+	cl.end(err)
+	return err
+}
+
+// values converts named values to the values expected by the methods
+// predating named parameters.
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	vs := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of named parameters")
+		}
+		vs[i] = a.Value
+	}
+	return vs, nil
+}
+
+// named converts values to named values.
+func named(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nvs
+}
+
+// sanitize replaces the string and numeric literals in query with "?", so
+// that the values inlined in statements aren't recorded, and collapses
+// white space.
+func sanitize(query string) string {
+	out := make([]byte, 0, len(query))
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		case c == '\'':
+			// Skip to the end of the string literal; '' is an escaped quote.
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			c = '?'
+		case isDigit(c) && (i == 0 || !isIdent(query[i-1])):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			c = '?'
+		}
+		if space && len(out) > 0 {
+			out = append(out, ' ')
+		}
+		space = false
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return isDigit(c) || c == '_' || c == '$' || c == '.' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
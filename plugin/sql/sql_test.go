@@ -0,0 +1,342 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/trace"
+)
+
+// fakeDriver is an in-memory driver whose statements are "SET key value",
+// "GET key" and "FAIL". It only implements the mandatory interfaces.
+type fakeDriver struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConnector struct {
+	d *fakeDriver
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, fields: strings.Fields(query)}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	d      *fakeDriver
+	fields []string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.fields[0] != "SET" {
+		return nil, errors.New("fake: bad statement")
+	}
+	s.d.mu.Lock()
+	s.d.data[s.fields[1]] = s.fields[2]
+	s.d.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.fields[0] != "GET" {
+		return nil, errors.New("fake: bad statement")
+	}
+	s.d.mu.Lock()
+	v := s.d.data[s.fields[1]]
+	s.d.mu.Unlock()
+	return &fakeRows{values: []string{v}}, nil
+}
+
+type fakeRows struct {
+	values []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+type testExporter struct {
+	spans []*trace.SpanData
+}
+
+func (e *testExporter) Export(sd *trace.SpanData) {
+	e.spans = append(e.spans, sd)
+}
+
+func init() {
+	stdsql.Register("opencensus-fake", Wrap(&fakeDriver{data: make(map[string]string)}))
+}
+
+func TestWrap(t *testing.T) {
+	db, err := stdsql.Open("opencensus-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB(t, db)
+}
+
+func TestWrapConnector(t *testing.T) {
+	d := &fakeDriver{data: make(map[string]string)}
+	testDB(t, stdsql.OpenDB(WrapConnector(fakeConnector{d})))
+}
+
+func testDB(t *testing.T, db *stdsql.DB) {
+	defer db.Close()
+	for _, v := range []*stats.View{CallCountView, ErrorCountView} {
+		if err := v.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		defer v.Unsubscribe()
+	}
+	var te testExporter
+	trace.RegisterExporter(&te)
+	defer trace.UnregisterExporter(&te)
+
+	ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "SET key 'value'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := db.QueryRowContext(ctx, "GET key").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v != "'value'" {
+		t.Errorf("got value %q, want %q", v, "'value'")
+	}
+	if _, err := db.ExecContext(ctx, "FAIL 42"); err == nil {
+		t.Errorf("FAIL statement: got no error, want an error")
+	}
+	trace.Flush()
+
+	parent := trace.FromContext(ctx).SpanContext().SpanID
+	var got []string
+	for _, sd := range te.spans {
+		if sd.ParentSpanID != parent {
+			t.Errorf("%s: got parent span %v, want %v", sd.Name, sd.ParentSpanID, parent)
+		}
+		s := sd.Name
+		if q, ok := sd.Attributes[QueryAttribute]; ok {
+			s += " " + q.(string)
+		}
+		if sd.Status.Code != 0 {
+			s += " failed"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"sql.Begin",
+		"sql.Prepare SET key ?",
+		"sql.Exec SET key ?",
+		"sql.Commit",
+		"sql.Prepare GET key",
+		"sql.Query GET key",
+		"sql.Prepare FAIL ?",
+		"sql.Exec FAIL ? failed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got spans %q, want %q", got, want)
+	}
+
+	counts := func(v *stats.View) map[string]int64 {
+		rows, err := v.RetrieveData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]int64)
+		for _, r := range rows {
+			m[r.Tags[0].Value] = int64(*r.Data.(*stats.CountData))
+		}
+		return m
+	}
+	wantCalls := map[string]int64{"Begin": 1, "Prepare": 3, "Exec": 2, "Commit": 1, "Query": 1}
+	if got := counts(CallCountView); !reflect.DeepEqual(got, wantCalls) {
+		t.Errorf("got call counts %v, want %v", got, wantCalls)
+	}
+	wantErrors := map[string]int64{"Exec": 1}
+	if got := counts(ErrorCountView); !reflect.DeepEqual(got, wantErrors) {
+		t.Errorf("got error counts %v, want %v", got, wantErrors)
+	}
+}
+
+// skipConn is a fakeConn implementing the optional interfaces forwarded
+// by conn. Its ExecContext always returns driver.ErrSkip.
+type skipConn struct {
+	*fakeConn
+	resets int
+}
+
+func (c *skipConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *skipConn) ResetSession(context.Context) error {
+	c.resets++
+	return nil
+}
+
+func (c *skipConn) IsValid() bool { return false }
+
+func (c *skipConn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.fakeConn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return convStmt{s.(*fakeStmt)}, nil
+}
+
+type skipConnector struct {
+	c *skipConn
+}
+
+func (c skipConnector) Connect(context.Context) (driver.Conn, error) { return c.c, nil }
+func (c skipConnector) Driver() driver.Driver                        { return c.c.d }
+
+// convStmt is a fakeStmt converting all its arguments to strings.
+type convStmt struct {
+	*fakeStmt
+}
+
+func (convStmt) ColumnConverter(int) driver.ValueConverter { return driver.String }
+
+func TestOptionalInterfaces(t *testing.T) {
+	sc := &skipConn{fakeConn: &fakeConn{d: &fakeDriver{data: make(map[string]string)}}}
+	c := &conn{parent: sc}
+	if err := c.ResetSession(context.Background()); err != nil || sc.resets != 1 {
+		t.Errorf("ResetSession() = %v with %d resets; want nil with 1 reset", err, sc.resets)
+	}
+	if c.IsValid() {
+		t.Errorf("IsValid() = true; want false")
+	}
+	if c := (&conn{parent: &fakeConn{}}); c.ResetSession(context.Background()) != nil || !c.IsValid() {
+		t.Errorf("conn without optional interfaces: got a reset error or an invalid connection")
+	}
+
+	s, err := c.Prepare("GET key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.(driver.ColumnConverter).ColumnConverter(0).ConvertValue(42); err != nil || got != "42" {
+		t.Errorf("ColumnConverter(0).ConvertValue(42) = %v, %v; want \"42\", nil", got, err)
+	}
+	s = &stmt{parent: &fakeStmt{}}
+	if got := s.(driver.ColumnConverter).ColumnConverter(0); got != driver.DefaultParameterConverter {
+		t.Errorf("ColumnConverter(0) = %v; want driver.DefaultParameterConverter", got)
+	}
+}
+
+func TestSkippedCall(t *testing.T) {
+	if err := CallCountView.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	defer CallCountView.Unsubscribe()
+	execCount := func() int64 {
+		rows, err := CallCountView.RetrieveData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range rows {
+			if r.Tags[0].Value == "Exec" {
+				return int64(*r.Data.(*stats.CountData))
+			}
+		}
+		return 0
+	}
+	before := execCount()
+	var te testExporter
+	trace.RegisterExporter(&te)
+	defer trace.UnregisterExporter(&te)
+
+	sc := &skipConn{fakeConn: &fakeConn{d: &fakeDriver{data: make(map[string]string)}}}
+	db := stdsql.OpenDB(WrapConnector(skipConnector{sc}))
+	defer db.Close()
+	ctx := trace.StartSpanWithOptions(context.Background(), "parent", trace.StartSpanOptions{Sampler: trace.AlwaysSample()})
+	// The connection skips ExecContext, so database/sql prepares the
+	// statement and executes it instead.
+	if _, err := db.ExecContext(ctx, "SET key value"); err != nil {
+		t.Fatal(err)
+	}
+	trace.Flush()
+
+	var got []string
+	for _, sd := range te.spans {
+		got = append(got, sd.Name)
+	}
+	if want := []string{"sql.Prepare", "sql.Exec"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got spans %q, want %q", got, want)
+	}
+	if got := execCount() - before; got != 1 {
+		t.Errorf("got %d Exec calls recorded, want 1", got)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT *\n  FROM users\tWHERE name = 'O''Brien' AND age > 3.5", "SELECT * FROM users WHERE name = ? AND age > ?"},
+		{"SELECT col1, t2.x FROM t2 WHERE y = $1", "SELECT col1, t2.x FROM t2 WHERE y = $1"},
+		{"INSERT INTO t VALUES (?, ?)", "INSERT INTO t VALUES (?, ?)"},
+	}
+	for _, tt := range tests {
+		if got := sanitize(tt.query); got != tt.want {
+			t.Errorf("sanitize(%q) = %q; want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"log"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// The following measures are recorded for each call to the wrapped drivers.
+// They are tagged with MethodKey.
+var (
+	CallCount  *stats.MeasureInt64
+	Latency    *stats.MeasureFloat64
+	ErrorCount *stats.MeasureInt64
+)
+
+// The following views are predefined for the measures above.
+// They need to be subscribed to for data to be collected.
+var (
+	CallCountView  *stats.View
+	LatencyView    *stats.View
+	ErrorCountView *stats.View
+)
+
+// MethodKey is the tag key whose value is the name of the
+// database method called: Query, Exec, Prepare, Begin, Commit or Rollback.
+var MethodKey tag.Key
+
+var latencyBucketBoundaries = []float64{0, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 5000, 10000, 20000, 50000, 100000}
+
+func init() {
+	var err error
+	if MethodKey, err = tag.NewKey("sql.method"); err != nil {
+		log.Fatalf("Cannot create sql.method key: %v", err)
+	}
+
+	if CallCount, err = stats.NewMeasureInt64("/opencensus.io/sql/calls", "Number of database calls", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/sql/calls: %v", err)
+	}
+	if Latency, err = stats.NewMeasureFloat64("/opencensus.io/sql/latency", "Latency of database calls in msecs", "ms"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/sql/latency: %v", err)
+	}
+	if ErrorCount, err = stats.NewMeasureInt64("/opencensus.io/sql/errors", "Number of database calls that failed", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/sql/errors: %v", err)
+	}
+
+	keys := []tag.Key{MethodKey}
+	if CallCountView, err = stats.NewView("opencensus.io/sql/calls/cumulative", "Number of database calls", keys, CallCount, stats.CountAggregation{}, stats.Cumulative{}); err != nil {
+		log.Fatalf("Cannot create view opencensus.io/sql/calls/cumulative: %v", err)
+	}
+	if LatencyView, err = stats.NewView("opencensus.io/sql/latency/distribution_cumulative", "Latency of database calls in msecs", keys, Latency, stats.DistributionAggregation(latencyBucketBoundaries), stats.Cumulative{}); err != nil {
+		log.Fatalf("Cannot create view opencensus.io/sql/latency/distribution_cumulative: %v", err)
+	}
+	if ErrorCountView, err = stats.NewView("opencensus.io/sql/errors/cumulative", "Number of database calls that failed", keys, ErrorCount, stats.CountAggregation{}, stats.Cumulative{}); err != nil {
+		log.Fatalf("Cannot create view opencensus.io/sql/errors/cumulative: %v", err)
+	}
+}
@@ -112,7 +112,9 @@ func (a *aggregatorCumulative) addSample(v interface{}, now time.Time) {
 }
 
 func (a *aggregatorCumulative) retrieveCollected(now time.Time) AggregationData {
-	return a.av
+	// The data is exported from other goroutines while
+	// the worker keeps adding samples to a.av.
+	return a.av.clone()
 }
 
 // aggregatorInterval indicates that the aggregation occurs over a
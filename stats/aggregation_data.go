@@ -29,6 +29,7 @@ type AggregationData interface {
 	multiplyByFraction(fraction float64) AggregationData
 	addToIt(other AggregationData)
 	clear()
+	clone() AggregationData
 }
 
 const epsilon = 1e-9
@@ -67,6 +68,10 @@ func (a *CountData) clear() {
 	*a = 0
 }
 
+func (a *CountData) clone() AggregationData {
+	return newCountData(int64(*a))
+}
+
 func (a *CountData) equal(other AggregationData) bool {
 	a2, ok := other.(*CountData)
 	if !ok {
@@ -119,6 +124,10 @@ func (a *SumData) clear() {
 	*a = 0
 }
 
+func (a *SumData) clone() AggregationData {
+	return newSumData(float64(*a))
+}
+
 func (a *SumData) equal(other AggregationData) bool {
 	a2, ok := other.(*SumData)
 	if !ok {
@@ -191,6 +200,10 @@ func (a *MeanData) clear() {
 	a.Mean = 0
 }
 
+func (a *MeanData) clone() AggregationData {
+	return newMeanData(a.Mean, a.Count)
+}
+
 func (a *MeanData) equal(other AggregationData) bool {
 	a2, ok := other.(*MeanData)
 	if !ok {
@@ -334,6 +347,12 @@ func (a *DistributionData) clear() {
 	}
 }
 
+func (a *DistributionData) clone() AggregationData {
+	ret := *a
+	ret.CountPerBucket = append([]int64(nil), a.CountPerBucket...)
+	return &ret
+}
+
 func (a *DistributionData) equal(other AggregationData) bool {
 	a2, ok := other.(*DistributionData)
 	if !ok {
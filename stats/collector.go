@@ -33,17 +33,54 @@ type collector struct {
 
 	// window is the window under which the aggregation is performed.
 	w Window
+
+	// maxRows is the maximum number of signatures, not counting the
+	// overflow signature. Zero means no limit.
+	maxRows int
+	// overflowSig is the signature into which samples of new signatures
+	// are aggregated once maxRows is reached.
+	overflowSig string
+	// overflowed is the number of samples aggregated into the overflow
+	// signature since the last call to takeOverflowed.
+	overflowed int64
 }
 
 func (c *collector) addSample(s string, v interface{}, now time.Time) {
 	aggregator, ok := c.signatures[s]
 	if !ok {
-		aggregator = c.w.newAggregator(now, c.a.newData())
-		c.signatures[s] = aggregator
+		if c.full() {
+			s = c.overflowSig
+			c.overflowed++
+			aggregator, ok = c.signatures[s]
+		}
+		if !ok {
+			aggregator = c.w.newAggregator(now, c.a.newData())
+			c.signatures[s] = aggregator
+		}
 	}
 	aggregator.addSample(v, now)
 }
 
+// full reports whether no new signature can be added to c.
+func (c *collector) full() bool {
+	if c.maxRows <= 0 {
+		return false
+	}
+	n := len(c.signatures)
+	if _, ok := c.signatures[c.overflowSig]; ok {
+		n--
+	}
+	return n >= c.maxRows
+}
+
+// takeOverflowed returns the number of samples aggregated into the overflow
+// signature since the last call and resets it.
+func (c *collector) takeOverflowed() int64 {
+	n := c.overflowed
+	c.overflowed = 0
+	return n
+}
+
 func (c *collector) collectedRows(keys []tag.Key, now time.Time) []*Row {
 	var rows []*Row
 	for sig, aggregator := range c.signatures {
@@ -58,6 +95,18 @@ func (c *collector) clearRows() {
 	c.signatures = make(map[string]aggregator)
 }
 
+// overflowSignature returns the signature of the overflow row of a view
+// with the given keys, in which every key has the value OverflowTagValue.
+func overflowSignature(keys []tag.Key) string {
+	vb := &tagencoding.Values{
+		Buffer: make([]byte, len(keys)),
+	}
+	for range keys {
+		vb.WriteValue([]byte(OverflowTagValue))
+	}
+	return string(vb.Bytes())
+}
+
 // encodeWithKeys encodes the map by using values
 // only associated with the keys provided.
 func encodeWithKeys(m *tag.Map, keys []tag.Key) []byte {
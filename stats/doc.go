@@ -74,6 +74,14 @@ backend by registering its exporter.
 Multiple exporters can be registered to upload the data to various
 different backends. Users need to unregister the exporters once they
 no longer are needed.
+
+Each exporter is handed view data from its own bounded queue, so a slow
+exporter doesn't delay the others. RegisterExporterWithOptions configures
+the size of the queue and what is dropped when it is full.
+
+Data is reported to the exporters periodically. Before a program exits,
+Shutdown should be called so that the data collected since the last
+report is exported and buffering exporters are flushed and closed.
 */
 package stats // import "go.opencensus.io/stats"
 
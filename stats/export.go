@@ -14,40 +14,244 @@
 
 package stats
 
-import "sync"
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"go.opencensus.io/internal/queue"
+	"go.opencensus.io/tag"
+)
 
 var (
 	exportersMu sync.RWMutex // guards exporters
-	exporters   = make(map[Exporter]struct{})
+	exporters   = make(map[Exporter]*exportQueue)
+)
+
+// The following measures are recorded about the exporters' queues. They are
+// tagged with ExporterKey, whose value is the Go type of the exporter.
+var (
+	// ExportQueueDepth is the number of view data waiting to be exported,
+	// recorded at the end of each reporting period.
+	ExportQueueDepth *MeasureInt64
+	// ExportDroppedCount is the number of view data dropped because
+	// an exporter's queue was full.
+	ExportDroppedCount *MeasureInt64
+
+	ExporterKey tag.Key
 )
 
+// createExportMeasures creates the measures recorded about the exporters'
+// queues. It is called once the default worker is started.
+func createExportMeasures() {
+	var err error
+	if ExporterKey, err = tag.NewKey("opencensus.exporter"); err != nil {
+		log.Fatalf("Cannot create opencensus.exporter key: %v", err)
+	}
+	if ExportQueueDepth, err = NewMeasureInt64("/opencensus.io/stats/export_queue_depth", "View data waiting to be exported", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/export_queue_depth: %v", err)
+	}
+	if ExportDroppedCount, err = NewMeasureInt64("/opencensus.io/stats/export_dropped_count", "View data dropped because the exporter queue was full", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/export_dropped_count: %v", err)
+	}
+}
+
 // Exporter exports the collected records as view data.
 //
-// The Export method should return quickly; if an
-// Exporter takes a significant amount of time to
-// process a ViewData, that work should be done on another goroutine.
+// Each exporter is called from its own goroutine, one view data at a time,
+// so that a slow exporter doesn't delay the others.
 //
 // The ViewData should not be modified.
 type Exporter interface {
 	Export(viewData *ViewData)
 }
 
-// RegisterExporter registers an exporter.
+// Flusher is an optional interface for exporters that buffer view data.
+// Flush should return once all the view data passed to Export has been
+// processed.
+//
+// Exporters that hold resources can also implement io.Closer; Close is
+// called on Shutdown after the exporter is flushed.
+type Flusher interface {
+	Flush()
+}
+
+// DropPolicy determines what happens to view data
+// reported to an exporter whose queue is full.
+type DropPolicy int
+
+const (
+	// DropNewest drops the view data being reported.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest view data in the queue.
+	DropOldest
+	// Block blocks the reporting of all views until there is room in
+	// the queue, for at most maxReportBlock per report. View data that
+	// still doesn't fit is then dropped, so that an exporter recording
+	// measurements cannot block the worker it is waiting for.
+	Block
+)
+
+const defaultExportQueueSize = 1024
+
+// maxReportBlock is how long reporting the views waits in total for
+// room in the queues of exporters with the Block policy.
+const maxReportBlock = time.Second
+
+// ExporterOptions configures how view data is handed to an exporter.
+type ExporterOptions struct {
+	// QueueSize is the number of view data that can be waiting to be
+	// exported. If zero, a default size is used.
+	QueueSize int
+
+	// DropPolicy determines what happens when the queue is full.
+	// The default is DropNewest.
+	DropPolicy DropPolicy
+}
+
+// exportQueue hands view data to an exporter on its own goroutine.
+type exportQueue struct {
+	q    *queue.Queue
+	tags *tag.Map // tags recorded with the queue's measurements
+
+	mu        sync.Mutex // guards latencies
+	latencies []float64  // export latencies not yet recorded, in ms
+}
+
+func newExportQueue(e Exporter, o ExporterOptions) *exportQueue {
+	size := o.QueueSize
+	if size <= 0 {
+		size = defaultExportQueueSize
+	}
+	tags, err := tag.NewMap(context.Background(), tag.Insert(ExporterKey, exporterName(e)))
+	if err != nil {
+		// The key and value are always valid.
+		panic(err)
+	}
+	eq := &exportQueue{tags: tags}
+	eq.q = queue.New(size, queue.DropPolicy(o.DropPolicy), func(item interface{}) {
+		start := time.Now()
+		e.Export(item.(*ViewData))
+		eq.addExportLatency(time.Since(start))
+	})
+	return eq
+}
+
+// RegisterExporter registers an exporter with the default options.
 // Collected data will be reported via all the
 // registered exporters. Once you don't want data
 // to be expoter on the registered exporter, use
 // UnregisterExporter.
 func RegisterExporter(e Exporter) {
+	RegisterExporterWithOptions(e, ExporterOptions{})
+}
+
+// RegisterExporterWithOptions registers an exporter that is handed
+// view data according to o. If e is already registered, its options
+// are replaced once the view data already queued is exported.
+func RegisterExporterWithOptions(e Exporter, o ExporterOptions) {
+	eq := newExportQueue(e, o)
 	exportersMu.Lock()
-	defer exportersMu.Unlock()
+	old := exporters[e]
+	exporters[e] = eq
+	exportersMu.Unlock()
 
-	exporters[e] = struct{}{}
+	if old != nil {
+		old.q.Close()
+	}
 }
 
-// UnregisterExporter unregisters an exporter.
+// UnregisterExporter unregisters an exporter. The view data
+// already queued for the exporter is exported and, if the exporter
+// implements Flusher, it is flushed before UnregisterExporter returns.
 func UnregisterExporter(e Exporter) {
 	exportersMu.Lock()
-	defer exportersMu.Unlock()
-
+	eq := exporters[e]
 	delete(exporters, e)
+	exportersMu.Unlock()
+
+	if eq == nil {
+		return
+	}
+	eq.q.Close()
+	if f, ok := e.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Flush reports the data collected for all subscribed views to the
+// registered exporters without waiting for the end of the reporting
+// period, waits for it to be exported, and then flushes the exporters
+// that implement Flusher.
+func Flush() {
+	report(false)
+
+	exportersMu.RLock()
+	es := make(map[Exporter]*exportQueue, len(exporters))
+	for e, eq := range exporters {
+		es[e] = eq
+	}
+	exportersMu.RUnlock()
+
+	for e, eq := range es {
+		eq.q.Flush()
+		if f, ok := e.(Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// Shutdown reports the data collected for all subscribed views, stops
+// the periodic reporting and unregisters all the exporters. Exporters
+// are flushed if they implement Flusher and closed if they implement
+// io.Closer. It returns ctx.Err() if ctx is done before the exporters
+// are, or the first error returned by Close otherwise.
+//
+// Measurements recorded after Shutdown are still aggregated and can be
+// retrieved with RetrieveData, but are not exported until the reporting
+// period is set again with SetReportingPeriod.
+func Shutdown(ctx context.Context) error {
+	report(true)
+
+	exportersMu.Lock()
+	es := exporters
+	exporters = make(map[Exporter]*exportQueue)
+	exportersMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for e, eq := range es {
+			eq.q.Close()
+			if f, ok := e.(Flusher); ok {
+				f.Flush()
+			}
+			if c, ok := e.(io.Closer); ok {
+				if cerr := c.Close(); err == nil {
+					err = cerr
+				}
+			}
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// report makes the worker report the collected data to the exporters
+// and waits for it to be queued.
+func report(stop bool) {
+	req := &reportReq{
+		now:  time.Now(),
+		stop: stop,
+		c:    make(chan bool),
+	}
+	defaultWorker.c <- req
+	<-req.c
 }
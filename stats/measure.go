@@ -33,6 +33,8 @@ type Measure interface {
 	addView(v *View)
 	removeView(v *View)
 	viewsCount() int
+	isEnabled() bool
+	setEnabled(enabled bool)
 }
 
 // Measurement is the numeric value measured when recording stats. Each measure
@@ -65,3 +67,27 @@ func DeleteMeasure(m Measure) error {
 	defaultWorker.c <- req
 	return <-req.err
 }
+
+// SetMeasureEnabled enables or disables recording of m globally.
+// Measurements of a disabled measure are dropped by Record before they
+// reach the views of the measure. Measures are enabled when created.
+func SetMeasureEnabled(m Measure, enabled bool) {
+	m.setEnabled(enabled)
+}
+
+// IsMeasureEnabled reports whether recording of m is enabled.
+func IsMeasureEnabled(m Measure) bool {
+	return m.isEnabled()
+}
+
+// measureOf returns the measure of measurement m, or nil if m is not a
+// known measurement.
+func measureOf(m Measurement) Measure {
+	switch m := m.(type) {
+	case *measurementInt64:
+		return m.m
+	case *measurementFloat64:
+		return m.m
+	}
+	return nil
+}
@@ -15,12 +15,15 @@
 
 package stats
 
+import "sync/atomic"
+
 // MeasureInt64 is a measure of type int64.
 type MeasureInt64 struct {
 	name        string
 	unit        string
 	description string
 	views       map[*View]bool
+	disabled    int32 // accessed atomically
 }
 
 // Name returns the name of the measure.
@@ -48,6 +51,16 @@ func (m *MeasureInt64) removeView(v *View) {
 
 func (m *MeasureInt64) viewsCount() int { return len(m.views) }
 
+func (m *MeasureInt64) isEnabled() bool { return atomic.LoadInt32(&m.disabled) == 0 }
+
+func (m *MeasureInt64) setEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&m.disabled, 0)
+	} else {
+		atomic.StoreInt32(&m.disabled, 1)
+	}
+}
+
 // M creates a new int64 measurement.
 // Use Record to record measurements.
 func (m *MeasureInt64) M(v int64) Measurement {
@@ -0,0 +1,199 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.opencensus.io/tag"
+)
+
+// The following measures are recorded about the stats library itself.
+// No view of them is subscribed by default; subscribe ObservabilityViews,
+// or views of your own, to export them through the registered exporters
+// like any other view.
+var (
+	// WorkerQueueLatency is the time in milliseconds between a call to
+	// Record and the aggregation of its measurements.
+	WorkerQueueLatency *MeasureFloat64
+	// RecordCount is the number of measurements passed to Record,
+	// recorded at the end of each reporting period.
+	RecordCount *MeasureInt64
+	// ViewCount is the number of registered views, recorded at the end
+	// of each reporting period.
+	ViewCount *MeasureInt64
+	// ViewRowCount is the number of rows of a view, tagged with ViewKey
+	// and recorded at the end of each reporting period.
+	ViewRowCount *MeasureInt64
+	// ExportLatency is the time in milliseconds a stats exporter took to
+	// export one view data, tagged with ExporterKey and recorded at the
+	// end of each reporting period. Trace exporters are timed by
+	// trace.ExportLatency.
+	ExportLatency *MeasureFloat64
+	// ExportErrorCount is the number of errors of an exporter, tagged with
+	// ExporterKey. It is recorded by the exporters themselves, with the
+	// context returned by ExporterContext.
+	ExportErrorCount *MeasureInt64
+)
+
+// The following views aggregate the measures recorded about the stats
+// library. They are not subscribed by default.
+var (
+	WorkerQueueLatencyView *View
+	RecordCountView        *View
+	ViewCountView          *View
+	ViewRowCountView       *View
+	ViewOverflowCountView  *View
+	ExportLatencyView      *View
+	ExportErrorCountView   *View
+	ExportQueueDepthView   *View
+	ExportDroppedCountView *View
+
+	// ObservabilityViews contains all the views above.
+	ObservabilityViews []*View
+)
+
+var (
+	selfLatencyBucketBoundaries = []float64{0, 0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 5000, 10000}
+
+	selfWindowMinute = Interval{Duration: time.Minute, Intervals: 6}
+)
+
+// createObservabilityMeasures creates the measures and views about the
+// stats library. It is called once the default worker is started, after
+// the export and view measures are created.
+func createObservabilityMeasures() {
+	var err error
+	if WorkerQueueLatency, err = NewMeasureFloat64("/opencensus.io/stats/worker_queue_latency", "Time between recording measurements and aggregating them", "ms"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/worker_queue_latency: %v", err)
+	}
+	if RecordCount, err = NewMeasureInt64("/opencensus.io/stats/record_count", "Measurements recorded", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/record_count: %v", err)
+	}
+	if ViewCount, err = NewMeasureInt64("/opencensus.io/stats/view_count", "Registered views", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/view_count: %v", err)
+	}
+	if ViewRowCount, err = NewMeasureInt64("/opencensus.io/stats/view_row_count", "Rows of a view", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/view_row_count: %v", err)
+	}
+	if ExportLatency, err = NewMeasureFloat64("/opencensus.io/stats/export_latency", "Time taken by an exporter to export view data", "ms"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/export_latency: %v", err)
+	}
+	if ExportErrorCount, err = NewMeasureInt64("/opencensus.io/stats/export_error_count", "Errors of an exporter", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/export_error_count: %v", err)
+	}
+
+	aggLatency := DistributionAggregation(selfLatencyBucketBoundaries)
+	WorkerQueueLatencyView, _ = NewView("opencensus.io/stats/worker_queue_latency/distribution_cumulative", "Time between recording measurements and aggregating them", nil, WorkerQueueLatency, aggLatency, Cumulative{})
+	RecordCountView, _ = NewView("opencensus.io/stats/record_count/cumulative", "Measurements recorded", nil, RecordCount, SumAggregation{}, Cumulative{})
+	ViewCountView, _ = NewView("opencensus.io/stats/view_count/minute", "Registered views", nil, ViewCount, MeanAggregation{}, selfWindowMinute)
+	ViewRowCountView, _ = NewView("opencensus.io/stats/view_row_count/minute", "Rows of each view", []tag.Key{ViewKey}, ViewRowCount, MeanAggregation{}, selfWindowMinute)
+	ViewOverflowCountView, _ = NewView("opencensus.io/stats/view_overflow_count/cumulative", "Measurements aggregated into the overflow row of each view", []tag.Key{ViewKey}, ViewOverflowCount, SumAggregation{}, Cumulative{})
+	ExportLatencyView, _ = NewView("opencensus.io/stats/export_latency/distribution_cumulative", "Time taken by each exporter to export view data", []tag.Key{ExporterKey}, ExportLatency, aggLatency, Cumulative{})
+	ExportErrorCountView, _ = NewView("opencensus.io/stats/export_error_count/cumulative", "Errors of each exporter", []tag.Key{ExporterKey}, ExportErrorCount, SumAggregation{}, Cumulative{})
+	ExportQueueDepthView, _ = NewView("opencensus.io/stats/export_queue_depth/minute", "View data waiting to be exported by each exporter", []tag.Key{ExporterKey}, ExportQueueDepth, MeanAggregation{}, selfWindowMinute)
+	ExportDroppedCountView, _ = NewView("opencensus.io/stats/export_dropped_count/cumulative", "View data dropped because the queue of an exporter was full", []tag.Key{ExporterKey}, ExportDroppedCount, SumAggregation{}, Cumulative{})
+
+	ObservabilityViews = []*View{
+		WorkerQueueLatencyView,
+		RecordCountView,
+		ViewCountView,
+		ViewRowCountView,
+		ViewOverflowCountView,
+		ExportLatencyView,
+		ExportErrorCountView,
+		ExportQueueDepthView,
+		ExportDroppedCountView,
+	}
+}
+
+// ExporterContext returns a copy of ctx whose tags contain ExporterKey
+// with the value used for exporter e by the export measures, which is the
+// Go type of e. Exporters use it to record ExportErrorCount and other
+// measures about themselves.
+func ExporterContext(ctx context.Context, e interface{}) context.Context {
+	tags, err := tag.NewMap(ctx, tag.Upsert(ExporterKey, exporterName(e)))
+	if err != nil {
+		return ctx
+	}
+	return tag.NewContext(ctx, tags)
+}
+
+func exporterName(e interface{}) string {
+	return fmt.Sprintf("%T", e)
+}
+
+// maxExportLatencies is the maximum number of export latencies kept for
+// an exporter between two reports. Latencies beyond it are not recorded.
+const maxExportLatencies = 4096
+
+// addExportLatency keeps the time taken to export one view data until
+// the next report. It is called from the queue's goroutine, which cannot
+// record it itself: the worker may be blocked on the queue.
+func (eq *exportQueue) addExportLatency(d time.Duration) {
+	eq.mu.Lock()
+	if len(eq.latencies) < maxExportLatencies {
+		eq.latencies = append(eq.latencies, float64(d)/float64(time.Millisecond))
+	}
+	eq.mu.Unlock()
+}
+
+func (eq *exportQueue) takeExportLatencies() []float64 {
+	eq.mu.Lock()
+	l := eq.latencies
+	eq.latencies = nil
+	eq.mu.Unlock()
+	return l
+}
+
+// recorded is called by the worker after handling a call to Record.
+func (w *worker) recorded(cmd *recordReq) {
+	w.recordCount += int64(len(cmd.ms))
+	if WorkerQueueLatency.viewsCount() > 0 {
+		d := time.Since(cmd.now)
+		w.record(tag.FromContext(context.Background()), WorkerQueueLatency.M(float64(d)/float64(time.Millisecond)))
+	}
+}
+
+// reportObservability records the measures about the library that are
+// recorded at the end of each reporting period. It is called before the
+// views are reported so that they include them.
+func (w *worker) reportObservability() {
+	empty := tag.FromContext(context.Background())
+	if w.recordCount > 0 {
+		w.record(empty, RecordCount.M(w.recordCount))
+		w.recordCount = 0
+	}
+	w.record(empty, ViewCount.M(int64(len(w.views))))
+	if ViewRowCount.viewsCount() > 0 {
+		for v := range w.views {
+			tm, err := tag.NewMap(context.Background(), tag.Insert(ViewKey, v.Name()))
+			if err != nil {
+				continue
+			}
+			w.record(tm, ViewRowCount.M(int64(len(v.collector.signatures))))
+		}
+	}
+	exportersMu.RLock()
+	for _, eq := range exporters {
+		for _, l := range eq.takeExportLatencies() {
+			w.record(eq.tags, ExportLatency.M(l))
+		}
+	}
+	exportersMu.RUnlock()
+}
@@ -0,0 +1,181 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"go.opencensus.io/internal/tagencoding"
+	"go.opencensus.io/tag"
+)
+
+// TagFilter selects rows by their tags.
+type TagFilter func(tags []tag.Tag) bool
+
+// TagEquals returns a filter selecting the rows whose tag k has the
+// given value. A row without a tag k has the empty value.
+func TagEquals(k tag.Key, value string) TagFilter {
+	return func(tags []tag.Tag) bool {
+		return tagValue(tags, k) == value
+	}
+}
+
+// TagNotEquals returns a filter selecting the rows whose tag k doesn't
+// have the given value.
+func TagNotEquals(k tag.Key, value string) TagFilter {
+	return func(tags []tag.Tag) bool {
+		return tagValue(tags, k) != value
+	}
+}
+
+// TagIn returns a filter selecting the rows whose tag k has one of
+// the given values.
+func TagIn(k tag.Key, values ...string) TagFilter {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(tags []tag.Tag) bool {
+		return set[tagValue(tags, k)]
+	}
+}
+
+// TagMatches returns a filter selecting the rows for whose tag k
+// match returns true.
+func TagMatches(k tag.Key, match func(value string) bool) TagFilter {
+	return func(tags []tag.Tag) bool {
+		return match(tagValue(tags, k))
+	}
+}
+
+func tagValue(tags []tag.Tag, k tag.Key) string {
+	for _, t := range tags {
+		if t.Key == k {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Query selects rows of a view and optionally re-aggregates them.
+type Query struct {
+	// Filters select the rows; a row is selected if all of them
+	// return true.
+	Filters []TagFilter
+
+	// Aggregate, if true, merges the selected rows that have the same
+	// values for Keys into a single row tagged only with Keys; as in the
+	// rows of a view, a key without a value is left out. Keys is
+	// typically a subset of the tag keys of the view; with no Keys, all
+	// the selected rows are merged into one, such as the total across
+	// all methods of an RPC view.
+	Aggregate bool
+	Keys      []tag.Key
+}
+
+// Apply returns the rows selected and re-aggregated by q. The rows are
+// not modified; merged rows have new AggregationData. It returns an
+// error if rows to merge have data of different kinds.
+func (q *Query) Apply(rows []*Row) ([]*Row, error) {
+	var selected []*Row
+	for _, r := range rows {
+		if q.matches(r.Tags) {
+			selected = append(selected, r)
+		}
+	}
+	if !q.Aggregate {
+		return selected, nil
+	}
+
+	keys := append([]tag.Key(nil), q.Keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name() < keys[j].Name() })
+	var out []*Row
+	bySig := make(map[string]*Row)
+	for _, r := range selected {
+		vb := &tagencoding.Values{Buffer: make([]byte, len(keys))}
+		tags := make([]tag.Tag, 0, len(keys))
+		for _, k := range keys {
+			v := tagValue(r.Tags, k)
+			vb.WriteValue([]byte(v))
+			if v != "" {
+				tags = append(tags, tag.Tag{Key: k, Value: v})
+			}
+		}
+		sig := string(vb.Bytes())
+		merged, ok := bySig[sig]
+		if !ok {
+			merged = &Row{Tags: tags, Data: r.Data.clone()}
+			bySig[sig] = merged
+			out = append(out, merged)
+			continue
+		}
+		data, err := MergeAggregationData(merged.Data, r.Data)
+		if err != nil {
+			return nil, err
+		}
+		merged.Data = data
+	}
+	return out, nil
+}
+
+func (q *Query) matches(tags []tag.Tag) bool {
+	for _, f := range q.Filters {
+		if !f(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns the current collected data for the view,
+// selected and re-aggregated by q.
+func (v *View) Query(q Query) ([]*Row, error) {
+	rows, err := v.RetrieveData()
+	if err != nil {
+		return nil, err
+	}
+	return q.Apply(rows)
+}
+
+// MergeAggregationData returns the aggregation of the data aggregated
+// by a and b, which must be of the same kind and, for distributions,
+// have the same bucket boundaries. Neither a nor b is modified.
+func MergeAggregationData(a, b AggregationData) (AggregationData, error) {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return nil, fmt.Errorf("cannot merge %T with %T", a, b)
+	}
+	if da, ok := a.(*DistributionData); ok {
+		if db := b.(*DistributionData); !equalBounds(da.bounds, db.bounds) {
+			return nil, fmt.Errorf("cannot merge distributions with bounds %v and %v", da.bounds, db.bounds)
+		}
+	}
+	merged := a.clone()
+	merged.addToIt(b)
+	return merged, nil
+}
+
+func equalBounds(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
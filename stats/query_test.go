@@ -0,0 +1,180 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opencensus.io/tag"
+)
+
+func TestQueryApply(t *testing.T) {
+	kMethod, _ := tag.NewKey("method")
+	kStatus, _ := tag.NewKey("status")
+	kUser, _ := tag.NewKey("user")
+	row := func(method, status string, count int64) *Row {
+		return &Row{[]tag.Tag{{Key: kMethod, Value: method}, {Key: kStatus, Value: status}}, newCountData(count)}
+	}
+	rows := []*Row{
+		row("get", "OK", 5),
+		row("get", "UNAVAILABLE", 1),
+		row("put", "OK", 3),
+		row("delete", "INTERNAL", 2),
+	}
+
+	tests := []struct {
+		label string
+		q     Query
+		want  []*Row
+	}{
+		{
+			"no filter",
+			Query{},
+			rows,
+		},
+		{
+			"equals",
+			Query{Filters: []TagFilter{TagEquals(kStatus, "OK")}},
+			[]*Row{rows[0], rows[2]},
+		},
+		{
+			"all filters",
+			Query{Filters: []TagFilter{TagNotEquals(kStatus, "OK"), TagIn(kMethod, "get", "put")}},
+			[]*Row{rows[1]},
+		},
+		{
+			"matches",
+			Query{Filters: []TagFilter{TagMatches(kMethod, func(v string) bool { return strings.HasPrefix(v, "de") })}},
+			[]*Row{rows[3]},
+		},
+		{
+			"by method",
+			Query{Aggregate: true, Keys: []tag.Key{kMethod}},
+			[]*Row{
+				{[]tag.Tag{{Key: kMethod, Value: "get"}}, newCountData(6)},
+				{[]tag.Tag{{Key: kMethod, Value: "put"}}, newCountData(3)},
+				{[]tag.Tag{{Key: kMethod, Value: "delete"}}, newCountData(2)},
+			},
+		},
+		{
+			"key without values",
+			Query{Filters: []TagFilter{TagEquals(kMethod, "put")}, Aggregate: true, Keys: []tag.Key{kMethod, kUser}},
+			[]*Row{{[]tag.Tag{{Key: kMethod, Value: "put"}}, newCountData(3)}},
+		},
+		{
+			"errors",
+			Query{Filters: []TagFilter{TagNotEquals(kStatus, "OK")}, Aggregate: true},
+			[]*Row{{[]tag.Tag{}, newCountData(3)}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.q.Apply(rows)
+		if err != nil {
+			t.Errorf("%s: Apply() = %v", tt.label, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.label, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%s: got row %v, want %v", tt.label, got[i], tt.want[i])
+			}
+		}
+	}
+	if got := rows[0].Data; !got.equal(newCountData(5)) {
+		t.Errorf("Apply() modified the data of a row: %v", got)
+	}
+}
+
+func TestMergeAggregationData(t *testing.T) {
+	d1, d2 := newDistributionData([]float64{10}), newDistributionData([]float64{10})
+	d1.addSample(float64(1))
+	d2.addSample(float64(20))
+	d2.addSample(float64(30))
+	got, err := MergeAggregationData(d1, d2)
+	if err != nil {
+		t.Fatalf("MergeAggregationData() = %v", err)
+	}
+	d := got.(*DistributionData)
+	if d.Count != 3 || d.Min != 1 || d.Max != 30 || d.Mean != 17 || d.CountPerBucket[0] != 1 || d.CountPerBucket[1] != 2 {
+		t.Errorf("got %+v, want 3 data points from 1 to 30 with a mean of 17", d)
+	}
+	if d1.Count != 1 {
+		t.Errorf("MergeAggregationData() modified its argument: %+v", d1)
+	}
+
+	got, err = MergeAggregationData(newMeanData(2, 2), newMeanData(5, 1))
+	if err != nil {
+		t.Fatalf("MergeAggregationData() = %v", err)
+	}
+	if want := newMeanData(3, 3); !got.equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, tt := range [][2]AggregationData{
+		{newCountData(1), newSumData(1)},
+		{newDistributionData([]float64{10}), newDistributionData([]float64{20})},
+	} {
+		if _, err := MergeAggregationData(tt[0], tt[1]); err == nil {
+			t.Errorf("MergeAggregationData(%v, %v) returned no error", tt[0], tt[1])
+		}
+	}
+}
+
+func Test_Worker_ViewQuery(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureFloat64("MQ/m1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureFloat64() = %v", err)
+	}
+	k1, _ := tag.NewKey("k1")
+	k2, _ := tag.NewKey("k2")
+	v, err := NewView("VQ1", "", []tag.Key{k1, k2}, m, SumAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer v.Unsubscribe()
+
+	for _, r := range []struct {
+		v1, v2 string
+		val    float64
+	}{
+		{"a", "x", 1},
+		{"a", "y", 2},
+		{"b", "x", 4},
+	} {
+		ts, err := tag.NewMap(context.Background(), tag.Insert(k1, r.v1), tag.Insert(k2, r.v2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Record(tag.NewContext(context.Background(), ts), m.M(r.val))
+	}
+
+	rows, err := v.Query(Query{Filters: []TagFilter{TagEquals(k2, "x")}, Aggregate: true})
+	if err != nil {
+		t.Fatalf("Query() = %v", err)
+	}
+	if want := newSumData(5); len(rows) != 1 || !rows[0].Data.equal(want) {
+		t.Errorf("Query() = %v; want a row with %v", rows, want)
+	}
+}
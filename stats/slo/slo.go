@@ -0,0 +1,395 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slo evaluates service level objectives in process, from the
+// data collected by views.
+//
+// An objective is a target fraction of good events, such as 99.9% of
+// RPCs succeeding or completing in less than 100ms. It is evaluated over
+// one or more windows, each counting events with views that have an
+// Interval window of the same duration. The burn rate of a window is its
+// fraction of bad events divided by the fraction allowed by the target;
+// an objective is burning when the burn rates of all its windows reach
+// their thresholds, which combines a long window that makes alerts
+// significant with a short one that ends them quickly once the problem
+// is fixed.
+//
+// The burn rates and remaining error budgets are recorded as measures,
+// and a callback is notified when an objective starts or stops burning
+// or exhausts its error budget.
+package slo // import "go.opencensus.io/stats/slo"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// The following measures are recorded each time objectives are
+// evaluated. They are tagged with ObjectiveKey, whose value is the name
+// of the objective.
+var (
+	// BurnRate is the burn rate of an objective over a window,
+	// also tagged with WindowKey, whose value is the name of the window.
+	BurnRate *stats.MeasureFloat64
+	// ErrorBudgetRemaining is the fraction of the error budget of an
+	// objective that remains, which is negative once it is exceeded.
+	ErrorBudgetRemaining *stats.MeasureFloat64
+
+	ObjectiveKey tag.Key
+	WindowKey    tag.Key
+)
+
+// The following views aggregate the measures above over the last minute.
+// They are not subscribed by default.
+var (
+	BurnRateView             *stats.View
+	ErrorBudgetRemainingView *stats.View
+)
+
+func init() {
+	var err error
+	if ObjectiveKey, err = tag.NewKey("opencensus.slo"); err != nil {
+		log.Fatalf("Cannot create opencensus.slo key: %v", err)
+	}
+	if WindowKey, err = tag.NewKey("opencensus.slo_window"); err != nil {
+		log.Fatalf("Cannot create opencensus.slo_window key: %v", err)
+	}
+	if BurnRate, err = stats.NewMeasureFloat64("/opencensus.io/slo/burn_rate", "Burn rate of the error budget of an objective over a window", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/slo/burn_rate: %v", err)
+	}
+	if ErrorBudgetRemaining, err = stats.NewMeasureFloat64("/opencensus.io/slo/error_budget_remaining", "Fraction of the error budget of an objective remaining", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/slo/error_budget_remaining: %v", err)
+	}
+
+	windowMinute := stats.Interval{Duration: time.Minute, Intervals: 6}
+	BurnRateView, _ = stats.NewView("opencensus.io/slo/burn_rate/minute", "Burn rate of the error budget of each objective over each window", []tag.Key{ObjectiveKey, WindowKey}, BurnRate, stats.MeanAggregation{}, windowMinute)
+	ErrorBudgetRemainingView, _ = stats.NewView("opencensus.io/slo/error_budget_remaining/minute", "Fraction of the error budget of each objective remaining", []tag.Key{ObjectiveKey}, ErrorBudgetRemaining, stats.MeanAggregation{}, windowMinute)
+}
+
+// Source returns the number of good events and the total number of
+// events over a window.
+type Source func() (good, total float64, err error)
+
+// Events returns a number of events.
+type Events func() (float64, error)
+
+// ViewEvents returns the number of events aggregated by the rows of view
+// v selected by filters. The view can have a count, sum, mean or
+// distribution aggregation, of which the number of events is the count,
+// the sum, the count and the count of data points.
+func ViewEvents(v *stats.View, filters ...stats.TagFilter) Events {
+	q := stats.Query{Filters: filters, Aggregate: true}
+	return func() (float64, error) {
+		return viewEvents(v, q)
+	}
+}
+
+// RatioSource returns a source counting the good events with good and
+// all the events with total, such as:
+//
+//	RatioSource(ViewEvents(v, stats.TagEquals(statusKey, "OK")), ViewEvents(v))
+func RatioSource(good, total Events) Source {
+	return func() (float64, float64, error) {
+		g, err := good()
+		if err != nil {
+			return 0, 0, err
+		}
+		t, err := total()
+		if err != nil {
+			return 0, 0, err
+		}
+		return g, t, nil
+	}
+}
+
+// LatencySource returns a source counting as good the events whose
+// value recorded in view v, which must have a distribution aggregation,
+// is less than threshold. The threshold must be one of the bucket
+// boundaries of the distribution. Only the rows selected by filters are
+// counted.
+func LatencySource(v *stats.View, threshold float64, filters ...stats.TagFilter) (Source, error) {
+	bounds, ok := v.Aggregation().(stats.DistributionAggregation)
+	if !ok {
+		return nil, fmt.Errorf("slo: view %q doesn't have a distribution aggregation", v.Name())
+	}
+	bucket := -1
+	for i, b := range bounds {
+		if b == threshold {
+			bucket = i
+		}
+	}
+	if bucket < 0 {
+		return nil, fmt.Errorf("slo: threshold %v is not a bucket boundary of view %q", threshold, v.Name())
+	}
+	q := stats.Query{Filters: filters, Aggregate: true}
+	return func() (float64, float64, error) {
+		rows, err := v.Query(q)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(rows) == 0 {
+			return 0, 0, nil
+		}
+		d, ok := rows[0].Data.(*stats.DistributionData)
+		if !ok {
+			return 0, 0, fmt.Errorf("slo: view %q has %T data", v.Name(), rows[0].Data)
+		}
+		var good int64
+		for _, n := range d.CountPerBucket[:bucket+1] {
+			good += n
+		}
+		return float64(good), float64(d.Count), nil
+	}, nil
+}
+
+func viewEvents(v *stats.View, q stats.Query) (float64, error) {
+	rows, err := v.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	switch d := rows[0].Data.(type) {
+	case *stats.CountData:
+		return float64(*d), nil
+	case *stats.SumData:
+		return float64(*d), nil
+	case *stats.MeanData:
+		return d.Count, nil
+	case *stats.DistributionData:
+		return float64(d.Count), nil
+	}
+	return 0, fmt.Errorf("slo: view %q has %T data", v.Name(), rows[0].Data)
+}
+
+// Objective is a service level objective.
+type Objective struct {
+	// Name identifies the objective in the measures and in Status.
+	Name string
+
+	// Target is the fraction of events that must be good, such as 0.999.
+	Target float64
+
+	// Windows are the windows over which burn rates are evaluated.
+	Windows []Window
+
+	// Budget counts the events over the period of the objective, such
+	// as 30 days, to compute the remaining error budget. If nil, the
+	// last window is used.
+	Budget Source
+}
+
+// Window is a window over which the burn rate of an objective is
+// evaluated.
+type Window struct {
+	// Name identifies the window in the measures, such as "1h".
+	Name string
+
+	// Source counts the events over the window.
+	Source Source
+
+	// Threshold is the burn rate from which the window is burning.
+	Threshold float64
+}
+
+// Status is the result of the evaluation of an objective.
+type Status struct {
+	Objective string
+
+	// BurnRates are the burn rates of the windows of the objective,
+	// in the same order. A window without events has a burn rate of 0.
+	BurnRates []float64
+
+	// BudgetRemaining is the fraction of the error budget remaining,
+	// negative once the budget is exceeded.
+	BudgetRemaining float64
+
+	// Burning is true if all the burn rates reached their thresholds.
+	Burning bool
+
+	// Exhausted is true if no error budget remains.
+	Exhausted bool
+}
+
+// Evaluator evaluates objectives.
+type Evaluator struct {
+	objectives []*objective
+	notify     func(Status)
+
+	mu sync.Mutex // serializes evaluations
+}
+
+type objective struct {
+	Objective
+	ctx  context.Context   // carries the tags of ErrorBudgetRemaining
+	ctxs []context.Context // carry the tags of BurnRate for each window
+
+	last Status
+}
+
+// NewEvaluator returns an evaluator of objectives. If notify is not nil,
+// it is called with the status of an objective whenever it starts or
+// stops burning or exhausting its error budget.
+func NewEvaluator(objectives []Objective, notify func(Status)) (*Evaluator, error) {
+	e := &Evaluator{notify: notify}
+	names := make(map[string]bool)
+	for _, o := range objectives {
+		if names[o.Name] {
+			return nil, fmt.Errorf("slo: duplicate objective %q", o.Name)
+		}
+		names[o.Name] = true
+		eo, err := newObjective(o)
+		if err != nil {
+			return nil, fmt.Errorf("slo: objective %q: %v", o.Name, err)
+		}
+		e.objectives = append(e.objectives, eo)
+	}
+	return e, nil
+}
+
+func newObjective(o Objective) (*objective, error) {
+	if o.Target <= 0 || o.Target >= 1 {
+		return nil, fmt.Errorf("target %v not in (0, 1)", o.Target)
+	}
+	if len(o.Windows) == 0 {
+		return nil, errors.New("no windows")
+	}
+	tags, err := tag.NewMap(context.Background(), tag.Insert(ObjectiveKey, o.Name))
+	if err != nil {
+		return nil, err
+	}
+	eo := &objective{Objective: o, ctx: tag.NewContext(context.Background(), tags)}
+	for _, w := range o.Windows {
+		if w.Source == nil {
+			return nil, fmt.Errorf("window %q has no source", w.Name)
+		}
+		if w.Threshold <= 0 {
+			return nil, fmt.Errorf("window %q threshold %v must be positive", w.Name, w.Threshold)
+		}
+		wtags, err := tag.NewMap(eo.ctx, tag.Insert(WindowKey, w.Name))
+		if err != nil {
+			return nil, err
+		}
+		eo.ctxs = append(eo.ctxs, tag.NewContext(context.Background(), wtags))
+	}
+	if eo.Budget == nil {
+		eo.Budget = o.Windows[len(o.Windows)-1].Source
+	}
+	return eo, nil
+}
+
+// Evaluate evaluates the objectives, records their burn rates and
+// remaining error budgets, and notifies the changes of their status.
+// An objective whose sources return an error is skipped; the first
+// error is returned after the other objectives are evaluated.
+func (e *Evaluator) Evaluate() ([]Status, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var statuses []Status
+	var firstErr error
+	for _, o := range e.objectives {
+		s, err := o.evaluate()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("slo: objective %q: %v", o.Name, err)
+			}
+			continue
+		}
+		for i, r := range s.BurnRates {
+			stats.Record(o.ctxs[i], BurnRate.M(r))
+		}
+		stats.Record(o.ctx, ErrorBudgetRemaining.M(s.BudgetRemaining))
+		if e.notify != nil && (s.Burning != o.last.Burning || s.Exhausted != o.last.Exhausted) {
+			e.notify(s)
+		}
+		o.last = s
+		statuses = append(statuses, s)
+	}
+	return statuses, firstErr
+}
+
+func (o *objective) evaluate() (Status, error) {
+	s := Status{
+		Objective: o.Name,
+		BurnRates: make([]float64, len(o.Windows)),
+		Burning:   true,
+	}
+	for i, w := range o.Windows {
+		good, total, err := w.Source()
+		if err != nil {
+			return Status{}, err
+		}
+		s.BurnRates[i] = o.errorRate(good, total) / (1 - o.Target)
+		if s.BurnRates[i] < w.Threshold {
+			s.Burning = false
+		}
+	}
+	good, total, err := o.Budget()
+	if err != nil {
+		return Status{}, err
+	}
+	s.BudgetRemaining = 1 - o.errorRate(good, total)/(1-o.Target)
+	s.Exhausted = s.BudgetRemaining <= 0
+	return s, nil
+}
+
+func (o *objective) errorRate(good, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return (total - good) / total
+}
+
+// Start evaluates the objectives every interval until stop is called.
+// The errors of the evaluations are passed to onError or, if it is nil,
+// logged.
+func (e *Evaluator) Start(interval time.Duration, onError func(error)) (stop func()) {
+	if onError == nil {
+		onError = func(err error) {
+			log.Printf("Failed to evaluate objectives: %v", err)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	done, stopped := make(chan bool), make(chan bool)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			if _, err := e.Evaluate(); err != nil {
+				onError(err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
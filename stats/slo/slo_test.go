@@ -0,0 +1,175 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+func newView(t *testing.T, name string, keys []tag.Key, m stats.Measure, agg stats.Aggregation, d time.Duration) *stats.View {
+	v, err := stats.NewView(name, "", keys, m, agg, stats.Interval{Duration: d, Intervals: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+var latency, count *stats.MeasureFloat64
+
+func init() {
+	var err error
+	if latency, err = stats.NewMeasureFloat64("/slo_test/latency", "", "ms"); err != nil {
+		panic(err)
+	}
+	if count, err = stats.NewMeasureFloat64("/slo_test/count", "", "1"); err != nil {
+		panic(err)
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	status, _ := tag.NewKey("slo_test_status")
+	views := []*stats.View{
+		newView(t, "slo_test/latency_1h", []tag.Key{status}, latency, stats.DistributionAggregation{10, 100}, time.Hour),
+		newView(t, "slo_test/count_5m", []tag.Key{status}, latency, stats.CountAggregation{}, 5*time.Minute),
+		newView(t, "slo_test/count_1h", []tag.Key{status}, latency, stats.CountAggregation{}, time.Hour),
+	}
+	defer func() {
+		for _, v := range views {
+			v.Unsubscribe()
+			v.Unregister()
+		}
+	}()
+	for _, v := range []*stats.View{BurnRateView, ErrorBudgetRemainingView} {
+		if err := v.Subscribe(); err != nil {
+			t.Fatal(err)
+		}
+		defer v.Unsubscribe()
+	}
+
+	record := func(code string, ms float64, n int) {
+		ts, err := tag.NewMap(context.Background(), tag.Insert(status, code))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			stats.Record(tag.NewContext(context.Background(), ts), latency.M(ms))
+		}
+	}
+	record("OK", 5, 90)
+	record("OK", 50, 5)
+	record("ERROR", 5, 5)
+
+	latencySource, err := LatencySource(views[0], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := stats.TagEquals(status, "OK")
+	var notified []Status
+	e, err := NewEvaluator([]Objective{
+		{
+			Name:   "availability",
+			Target: 0.9,
+			Windows: []Window{
+				{Name: "5m", Source: RatioSource(ViewEvents(views[1], ok), ViewEvents(views[1])), Threshold: 2},
+				{Name: "1h", Source: RatioSource(ViewEvents(views[2], ok), ViewEvents(views[2])), Threshold: 0.5},
+			},
+		},
+		{
+			Name:    "latency",
+			Target:  0.8,
+			Windows: []Window{{Name: "1h", Source: latencySource, Threshold: 0.1}},
+		},
+	}, func(s Status) { notified = append(notified, s) })
+	if err != nil {
+		t.Fatalf("NewEvaluator() = %v", err)
+	}
+
+	// 95 of 100 events are OK: the error rate is 0.05 for a budget of
+	// 0.1. Only the 1h window reaches its threshold.
+	statuses, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("Evaluate() = %v", err)
+	}
+	if got := statuses[0]; got.Burning || got.Exhausted || math.Abs(got.BurnRates[0]-0.5) > 1e-9 || math.Abs(got.BurnRates[1]-0.5) > 1e-9 || math.Abs(got.BudgetRemaining-0.5) > 1e-9 {
+		t.Errorf("got %+v, want burn rates of 0.5 and half of the budget remaining", got)
+	}
+	// 95 of 100 events are faster than 10ms: the error rate is 0.05 for
+	// a budget of 0.2.
+	if got := statuses[1]; !got.Burning || got.Exhausted || math.Abs(got.BurnRates[0]-0.25) > 1e-9 || math.Abs(got.BudgetRemaining-0.75) > 1e-9 {
+		t.Errorf("got %+v, want a burn rate of 0.25 and 0.75 of the budget remaining", got)
+	}
+	if len(notified) != 1 || notified[0].Objective != "latency" {
+		t.Errorf("got notifications %+v, want one for latency", notified)
+	}
+
+	// Evaluating again without changes notifies nothing.
+	if _, err := e.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() = %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("got notifications %+v, want one", notified)
+	}
+
+	rows, err := BurnRateView.RetrieveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &stats.Row{
+		Tags: []tag.Tag{{Key: ObjectiveKey, Value: "latency"}, {Key: WindowKey, Value: "1h"}},
+	}
+	var found bool
+	for _, r := range rows {
+		if len(r.Tags) == 2 && r.Tags[0] == want.Tags[0] && r.Tags[1] == want.Tags[1] {
+			found = true
+			if got := r.Data.(*stats.MeanData).Mean; math.Abs(got-0.25) > 1e-9 {
+				t.Errorf("got burn rate %v, want 0.25", got)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("got rows %v, want a row tagged %v", rows, want.Tags)
+	}
+}
+
+func TestNewEvaluatorErrors(t *testing.T) {
+	source := Source(func() (float64, float64, error) { return 1, 1, nil })
+	for _, o := range []Objective{
+		{Name: "target", Target: 1, Windows: []Window{{Name: "1h", Source: source, Threshold: 1}}},
+		{Name: "no windows", Target: 0.9},
+		{Name: "no source", Target: 0.9, Windows: []Window{{Name: "1h", Threshold: 1}}},
+		{Name: "threshold", Target: 0.9, Windows: []Window{{Name: "1h", Source: source}}},
+	} {
+		if _, err := NewEvaluator([]Objective{o}, nil); err == nil {
+			t.Errorf("%s: got no error, want an error", o.Name)
+		}
+	}
+
+	v, err := stats.NewView("slo_test/count", "", nil, count, stats.CountAggregation{}, stats.Cumulative{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LatencySource(v, 10); err == nil {
+		t.Error("LatencySource() of a count view: got no error, want an error")
+	}
+}
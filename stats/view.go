@@ -18,6 +18,7 @@ package stats
 import (
 	"bytes"
 	"fmt"
+	"log"
 	"reflect"
 	"sync/atomic"
 	"time"
@@ -25,6 +26,28 @@ import (
 	"go.opencensus.io/tag"
 )
 
+// ViewOverflowCount is the number of measurements aggregated into the
+// overflow row of a view because the view reached its maximum number of
+// rows. It is tagged with ViewKey, whose value is the name of the view, and
+// recorded at the end of each reporting period.
+var (
+	ViewOverflowCount *MeasureInt64
+
+	ViewKey tag.Key
+)
+
+// createViewMeasures creates the measures recorded about views. It is
+// called once the default worker is started.
+func createViewMeasures() {
+	var err error
+	if ViewKey, err = tag.NewKey("opencensus.view"); err != nil {
+		log.Fatalf("Cannot create opencensus.view key: %v", err)
+	}
+	if ViewOverflowCount, err = NewMeasureInt64("/opencensus.io/stats/view_overflow_count", "Measurements aggregated into the overflow row of a view", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/stats/view_overflow_count: %v", err)
+	}
+}
+
 // View allows users to filter and aggregate the recorded events
 // over a time window. Each view has to be registered to enable
 // data retrieval. Use NewView to initiate new views.
@@ -67,7 +90,7 @@ func NewView(name, description string, keys []tag.Key, measure Measure, agg Aggr
 		tagKeys:     keys,
 		m:           measure,
 		start:       time.Time{},
-		collector:   &collector{make(map[string]aggregator), agg, window},
+		collector:   &collector{signatures: make(map[string]aggregator), a: agg, w: window},
 	}, nil
 }
 
@@ -121,6 +144,26 @@ func (v *View) Measure() Measure {
 	return v.m
 }
 
+// OverflowTagValue is the value of every tag of the overflow row of a view
+// whose number of rows is limited. See SetMaxRows.
+const OverflowTagValue = "<overflow>"
+
+// SetMaxRows limits the number of rows of the view, which is the number of
+// distinct combinations of the values of its tag keys, to n. Once n rows
+// exist, measurements with new combinations are aggregated into a single
+// overflow row, whose tags all have the value OverflowTagValue, and counted
+// by ViewOverflowCount. If n is zero or negative, the number of rows is not
+// limited, which is the default.
+func (v *View) SetMaxRows(n int) {
+	req := &setMaxRowsReq{
+		v: v,
+		n: n,
+		c: make(chan bool),
+	}
+	defaultWorker.c <- req
+	<-req.c
+}
+
 func (v *View) collectedRows(now time.Time) []*Row {
 	return v.collector.collectedRows(v.tagKeys, now)
 }
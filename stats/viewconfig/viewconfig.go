@@ -0,0 +1,350 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package viewconfig defines views from JSON or YAML configuration, so
+// that the views collected and exported by a program can be changed
+// without code changes.
+//
+// A configuration lists views of measures already created by the program:
+//
+//	views:
+//	- name: grpc.io/server/latency_by_tenant
+//	  measure: /grpc.io/server/server_elapsed_time
+//	  tag_keys: [grpc.method, tenant]
+//	  aggregation:
+//	    type: distribution
+//	    bounds: [0, 10, 100, 1000]
+//	  window:
+//	    type: interval
+//	    duration: 1m
+//
+// The aggregation types are count, sum, mean and distribution (with
+// bounds). The window types are cumulative, the default, and interval
+// (with duration and optionally intervals).
+//
+// A Set subscribes the views of a configuration and, when a new
+// configuration is applied, replaces the views that changed and
+// unregisters the views that were removed.
+package viewconfig // import "go.opencensus.io/stats/viewconfig"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is the view configuration.
+type Config struct {
+	Views []View `yaml:"views" json:"views"`
+}
+
+// View is the configuration of a stats.View.
+type View struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+
+	// Measure is the name of the measure of the view, which
+	// must have been created when the view is built.
+	Measure string `yaml:"measure" json:"measure"`
+
+	TagKeys     []string    `yaml:"tag_keys" json:"tag_keys"`
+	Aggregation Aggregation `yaml:"aggregation" json:"aggregation"`
+	Window      Window      `yaml:"window" json:"window"`
+}
+
+// Aggregation is the configuration of one of the stats aggregations.
+type Aggregation struct {
+	// Type is one of count, sum, mean and distribution.
+	Type string `yaml:"type" json:"type"`
+
+	// Bounds are the bucket boundaries of a distribution.
+	Bounds []float64 `yaml:"bounds" json:"bounds"`
+}
+
+// Window is the configuration of one of the stats windows.
+type Window struct {
+	// Type is cumulative or interval. If empty, it is cumulative.
+	Type string `yaml:"type" json:"type"`
+
+	// Duration is the duration of an interval window,
+	// such as "1m" or "1h".
+	Duration string `yaml:"duration" json:"duration"`
+
+	// Intervals is the number of sub-intervals of an interval
+	// window. If zero, 6 are used.
+	Intervals int `yaml:"intervals" json:"intervals"`
+}
+
+// Load reads the configuration in the named JSON or YAML file.
+func Load(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a JSON or YAML configuration.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	// JSON is a subset of YAML, so a single decoder handles both.
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("viewconfig: %v", err)
+	}
+	return &c, nil
+}
+
+// NewViews returns the views described by c. The views are neither
+// registered nor subscribed.
+func (c *Config) NewViews() ([]*stats.View, error) {
+	names := make(map[string]bool)
+	views := make([]*stats.View, len(c.Views))
+	for i := range c.Views {
+		vc := &c.Views[i]
+		if names[vc.Name] {
+			return nil, fmt.Errorf("viewconfig: duplicate view %q", vc.Name)
+		}
+		names[vc.Name] = true
+		v, err := vc.view()
+		if err != nil {
+			return nil, fmt.Errorf("viewconfig: view %q: %v", vc.Name, err)
+		}
+		views[i] = v
+	}
+	return views, nil
+}
+
+func (vc *View) view() (*stats.View, error) {
+	m := stats.FindMeasure(vc.Measure)
+	if m == nil {
+		return nil, fmt.Errorf("measure %q not found", vc.Measure)
+	}
+	keys := make([]tag.Key, len(vc.TagKeys))
+	for i, name := range vc.TagKeys {
+		k, err := tag.NewKey(name)
+		if err != nil {
+			return nil, fmt.Errorf("tag key %q: %v", name, err)
+		}
+		keys[i] = k
+	}
+	agg, err := vc.Aggregation.aggregation()
+	if err != nil {
+		return nil, err
+	}
+	window, err := vc.Window.window()
+	if err != nil {
+		return nil, err
+	}
+	return stats.NewView(vc.Name, vc.Description, keys, m, agg, window)
+}
+
+func (a *Aggregation) aggregation() (stats.Aggregation, error) {
+	switch a.Type {
+	case "count":
+		return stats.CountAggregation{}, nil
+	case "sum":
+		return stats.SumAggregation{}, nil
+	case "mean":
+		return stats.MeanAggregation{}, nil
+	case "distribution":
+		if len(a.Bounds) == 0 {
+			return nil, fmt.Errorf("missing distribution bounds")
+		}
+		for i := 1; i < len(a.Bounds); i++ {
+			if a.Bounds[i] <= a.Bounds[i-1] {
+				return nil, fmt.Errorf("distribution bounds %v not increasing", a.Bounds)
+			}
+		}
+		return stats.DistributionAggregation(a.Bounds), nil
+	case "":
+		return nil, fmt.Errorf("missing aggregation type")
+	}
+	return nil, fmt.Errorf("unknown aggregation type %q", a.Type)
+}
+
+func (w *Window) window() (stats.Window, error) {
+	switch w.Type {
+	case "", "cumulative":
+		return stats.Cumulative{}, nil
+	case "interval":
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("window duration: %v", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("window duration %v must be positive", d)
+		}
+		n := w.Intervals
+		if n == 0 {
+			n = 6
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("window intervals %d must be positive", n)
+		}
+		return stats.Interval{Duration: d, Intervals: n}, nil
+	}
+	return nil, fmt.Errorf("unknown window type %q", w.Type)
+}
+
+// Set is the set of views subscribed from a configuration.
+// The zero value is an empty set ready to use.
+type Set struct {
+	mu    sync.Mutex
+	views map[string]*setView
+}
+
+type setView struct {
+	config View
+	v      *stats.View
+}
+
+// Apply makes the views of the set those described by c. The views that
+// are not in c are unsubscribed and unregistered, and the views in c that
+// are new or whose configuration changed are registered and subscribed.
+// The views whose configuration is unchanged keep their collected data.
+//
+// If c is invalid, the set is not changed. An error registering or
+// subscribing a view, for instance because a view with the same name was
+// registered by the program, is returned after the other views are
+// applied.
+func (s *Set) Apply(c *Config) error {
+	views, err := c.NewViews()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make(map[string]*setView, len(views))
+	var added []*setView
+	for i, v := range views {
+		sv := s.views[v.Name()]
+		if sv == nil || !reflect.DeepEqual(sv.config, c.Views[i]) {
+			sv = &setView{config: c.Views[i], v: v}
+			added = append(added, sv)
+		}
+		next[v.Name()] = sv
+	}
+
+	var firstErr error
+	for name, sv := range s.views {
+		if next[name] == sv {
+			continue
+		}
+		if err := unsubscribe(sv.v); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, sv := range added {
+		if err := sv.v.Subscribe(); err != nil {
+			delete(next, sv.v.Name())
+			if firstErr == nil {
+				firstErr = fmt.Errorf("viewconfig: %v", err)
+			}
+		}
+	}
+	s.views = next
+	return firstErr
+}
+
+// unsubscribe unsubscribes and unregisters v.
+func unsubscribe(v *stats.View) error {
+	if err := v.Unsubscribe(); err != nil {
+		return fmt.Errorf("viewconfig: %v", err)
+	}
+	if err := v.Unregister(); err != nil {
+		return fmt.Errorf("viewconfig: %v", err)
+	}
+	return nil
+}
+
+// Load reads the configuration in the named JSON or YAML file and
+// applies it to s.
+func (s *Set) Load(filename string) error {
+	c, err := Load(filename)
+	if err != nil {
+		return err
+	}
+	return s.Apply(c)
+}
+
+// Views returns the views of the set.
+func (s *Set) Views() []*stats.View {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	views := make([]*stats.View, 0, len(s.views))
+	for _, sv := range s.views {
+		views = append(views, sv.v)
+	}
+	return views
+}
+
+// Watch checks the named file every interval and applies it to s when
+// its modification time or size changes, until stop is called. The
+// errors loading the file are passed to onError or, if it is nil,
+// logged. Watch doesn't load the file initially; call Load before.
+// Once stop returns, the file is no longer applied.
+func (s *Set) Watch(filename string, interval time.Duration, onError func(error)) (stop func()) {
+	if onError == nil {
+		onError = func(err error) {
+			log.Printf("Failed to reload the view configuration %s: %v", filename, err)
+		}
+	}
+	var modTime time.Time
+	var size int64
+	if fi, err := os.Stat(filename); err == nil {
+		modTime, size = fi.ModTime(), fi.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	done, stopped := make(chan bool), make(chan bool)
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			fi, err := os.Stat(filename)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			if fi.ModTime().Equal(modTime) && fi.Size() == size {
+				continue
+			}
+			modTime, size = fi.ModTime(), fi.Size()
+			if err := s.Load(filename); err != nil {
+				onError(err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
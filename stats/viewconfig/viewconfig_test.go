@@ -0,0 +1,184 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package viewconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opencensus.io/stats"
+)
+
+func init() {
+	if _, err := stats.NewMeasureFloat64("/viewconfig/latency", "", "ms"); err != nil {
+		panic(err)
+	}
+}
+
+const yamlConfig = `
+views:
+- name: viewconfig/latency
+  measure: /viewconfig/latency
+  tag_keys: [method]
+  aggregation: {type: distribution, bounds: [0, 10, 100]}
+- name: viewconfig/count
+  measure: /viewconfig/latency
+  aggregation: {type: count}
+  window: {type: interval, duration: 1m}
+`
+
+const jsonConfig = `{
+  "views": [
+    {
+      "name": "viewconfig/latency",
+      "measure": "/viewconfig/latency",
+      "tag_keys": ["method"],
+      "aggregation": {"type": "distribution", "bounds": [0, 10, 100]}
+    },
+    {
+      "name": "viewconfig/count",
+      "measure": "/viewconfig/latency",
+      "aggregation": {"type": "count"},
+      "window": {"type": "interval", "duration": "1m"}
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	for _, config := range []string{yamlConfig, jsonConfig} {
+		c, err := Parse([]byte(config))
+		if err != nil {
+			t.Fatalf("Parse() = %v", err)
+		}
+		views, err := c.NewViews()
+		if err != nil {
+			t.Fatalf("NewViews() = %v", err)
+		}
+		if len(views) != 2 {
+			t.Fatalf("got %d views, want 2", len(views))
+		}
+		v := views[0]
+		if got, want := v.Aggregation(), stats.DistributionAggregation([]float64{0, 10, 100}); !reflect.DeepEqual(got, want) {
+			t.Errorf("got aggregation %v, want %v", got, want)
+		}
+		if got := v.TagKeys(); len(got) != 1 || got[0].Name() != "method" {
+			t.Errorf("got tag keys %v, want [method]", got)
+		}
+		if got, want := views[1].Window(), (stats.Interval{Duration: time.Minute, Intervals: 6}); got != want {
+			t.Errorf("got window %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	for _, config := range []string{
+		`views: [{name: v, measure: /viewconfig/unknown, aggregation: {type: count}}]`,
+		`views: [{name: v, measure: /viewconfig/latency}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: median}}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: distribution}}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: distribution, bounds: [2, 1]}}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: count}, window: {type: interval}}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: count}, window: {type: sliding}}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: count}, unknown: 1}]`,
+		`views: [{name: v, measure: /viewconfig/latency, aggregation: {type: count}}, {name: v, measure: /viewconfig/latency, aggregation: {type: sum}}]`,
+	} {
+		c, err := Parse([]byte(config))
+		if err == nil {
+			_, err = c.NewViews()
+		}
+		if err == nil {
+			t.Errorf("%q: got no error, want an error", config)
+		}
+	}
+}
+
+func TestSetApply(t *testing.T) {
+	var s Set
+	c, err := Parse([]byte(yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Apply(c); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	latency, count := stats.FindView("viewconfig/latency"), stats.FindView("viewconfig/count")
+	if latency == nil || count == nil {
+		t.Fatalf("views not registered: %v, %v", latency, count)
+	}
+
+	// Adding a tag key replaces the view; the unchanged view is kept.
+	c.Views[0].TagKeys = append(c.Views[0].TagKeys, "tenant")
+	if err := s.Apply(c); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	if v := stats.FindView("viewconfig/latency"); v == nil || v == latency || len(v.TagKeys()) != 2 {
+		t.Errorf("got view %v, want a new view with 2 tag keys", v)
+	}
+	if v := stats.FindView("viewconfig/count"); v != count {
+		t.Errorf("got view %v, want the unchanged view", v)
+	}
+
+	c.Views = c.Views[1:]
+	if err := s.Apply(c); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	if v := stats.FindView("viewconfig/latency"); v != nil {
+		t.Errorf("removed view is still registered: %v", v)
+	}
+	if got := s.Views(); len(got) != 1 || got[0] != count {
+		t.Errorf("Views() = %v, want [%v]", got, count)
+	}
+
+	if err := s.Apply(&Config{}); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	if v := stats.FindView("viewconfig/count"); v != nil {
+		t.Errorf("removed view is still registered: %v", v)
+	}
+}
+
+func TestSetWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "viewconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "views.yaml")
+	if err := ioutil.WriteFile(filename, []byte("views: []"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var s Set
+	defer s.Apply(&Config{})
+	if err := s.Load(filename); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	stop := s.Watch(filename, 10*time.Millisecond, func(err error) { t.Error(err) })
+	defer stop()
+
+	if err := ioutil.WriteFile(filename, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(s.Views()) != 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("got views %v after reload, want 2 views", s.Views())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
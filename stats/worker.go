@@ -27,6 +27,9 @@ import (
 func init() {
 	defaultWorker = newWorker()
 	go defaultWorker.start()
+	createExportMeasures()
+	createViewMeasures()
+	createObservabilityMeasures()
 }
 
 type worker struct {
@@ -34,6 +37,11 @@ type worker struct {
 	measures       map[Measure]bool
 	viewsByName    map[string]*View
 	views          map[*View]bool
+	filters        map[Measure]RecordFilter
+
+	// recordCount is the number of measurements passed to Record since
+	// the last report.
+	recordCount int64
 
 	timer      *time.Ticker
 	c          chan command
@@ -179,7 +187,12 @@ func (v *View) RetrieveData() ([]*Row, error) {
 
 // Record records one or multiple measurements with the same tags at once.
 // If there are any tags in the context, measurements will be tagged with them.
+// Measurements of disabled measures are dropped.
 func Record(ctx context.Context, ms ...Measurement) {
+	ms = enabledMeasurements(ms)
+	if len(ms) == 0 {
+		return
+	}
 	req := &recordReq{
 		now: time.Now(),
 		tm:  tag.FromContext(ctx),
@@ -188,6 +201,45 @@ func Record(ctx context.Context, ms ...Measurement) {
 	defaultWorker.c <- req
 }
 
+// enabledMeasurements returns the measurements in ms whose measures are
+// enabled. It returns ms itself if all of them are.
+func enabledMeasurements(ms []Measurement) []Measurement {
+	for i, m := range ms {
+		if mm := measureOf(m); mm != nil && !mm.isEnabled() {
+			enabled := append([]Measurement(nil), ms[:i]...)
+			for _, m := range ms[i+1:] {
+				if mm := measureOf(m); mm == nil || mm.isEnabled() {
+					enabled = append(enabled, m)
+				}
+			}
+			return enabled
+		}
+	}
+	return ms
+}
+
+// RecordFilter inspects a measurement of measure m before it is aggregated
+// into the views of m. The value is an int64 for a MeasureInt64 and a
+// float64 for a MeasureFloat64. The filter returns the value and tags to
+// aggregate, which may be rewritten, and false if the measurement is to be
+// dropped. A returned value of the wrong type drops the measurement.
+//
+// Filters are called from the goroutine that aggregates all measurements,
+// so they must be fast and must not call functions of this package.
+type RecordFilter func(m Measure, value interface{}, tags *tag.Map) (interface{}, *tag.Map, bool)
+
+// SetRecordFilter sets the filter applied to the measurements of m.
+// A nil filter removes the filter of m.
+func SetRecordFilter(m Measure, f RecordFilter) {
+	req := &setRecordFilterReq{
+		m: m,
+		f: f,
+		c: make(chan bool),
+	}
+	defaultWorker.c <- req
+	<-req.c
+}
+
 // SetReportingPeriod sets the interval between reporting aggregated views in
 // the program. If duration is less than or
 // equal to zero, it enables the default behavior.
@@ -208,6 +260,7 @@ func newWorker() *worker {
 		measures:       make(map[Measure]bool),
 		viewsByName:    make(map[string]*View),
 		views:          make(map[*View]bool),
+		filters:        make(map[Measure]RecordFilter),
 		timer:          time.NewTicker(defaultReportingDuration),
 		c:              make(chan command),
 		quit:           make(chan bool),
@@ -221,6 +274,9 @@ func (w *worker) start() {
 		case cmd := <-w.c:
 			if cmd != nil {
 				cmd.handleCommand(w)
+				if r, ok := cmd.(*recordReq); ok {
+					w.recorded(r)
+				}
 			}
 		case <-w.timer.C:
 			w.reportUsage(time.Now())
@@ -278,6 +334,19 @@ func (w *worker) tryRegisterView(v *View) error {
 }
 
 func (w *worker) reportUsage(now time.Time) {
+	w.reportObservability()
+
+	// The queues are added to without holding exportersMu: an exporter
+	// blocked recording measurements while the worker waits for room in
+	// its queue must not also block the registration of exporters.
+	exportersMu.RLock()
+	queues := make([]*exportQueue, 0, len(exporters))
+	for _, eq := range exporters {
+		queues = append(queues, eq)
+	}
+	exportersMu.RUnlock()
+	deadline := time.Now().Add(maxReportBlock)
+
 	for v := range w.views {
 		if !v.isSubscribed() {
 			continue
@@ -289,13 +358,46 @@ func (w *worker) reportUsage(now time.Time) {
 			End:   time.Now(),
 			Rows:  rows,
 		}
-		exportersMu.Lock()
-		for e := range exporters {
-			e.Export(viewData)
+		for _, eq := range queues {
+			if n := eq.q.AddBefore(viewData, deadline); n > 0 {
+				w.record(eq.tags, ExportDroppedCount.M(int64(n)))
+			}
 		}
-		exportersMu.Unlock()
 		if _, ok := v.Window().(*Cumulative); !ok {
 			v.clearRows()
 		}
 	}
+	exportersMu.RLock()
+	for _, eq := range exporters {
+		w.record(eq.tags, ExportQueueDepth.M(int64(eq.q.Len())))
+	}
+	exportersMu.RUnlock()
+	w.reportOverflow()
+}
+
+// reportOverflow records the number of measurements aggregated into the
+// overflow row of each view since the last report.
+func (w *worker) reportOverflow() {
+	for v := range w.views {
+		n := v.collector.takeOverflowed()
+		if n == 0 {
+			continue
+		}
+		tm, err := tag.NewMap(context.Background(), tag.Insert(ViewKey, v.Name()))
+		if err != nil {
+			continue
+		}
+		w.record(tm, ViewOverflowCount.M(n))
+	}
+}
+
+// record records measurements from the worker goroutine itself,
+// which cannot use Record without blocking.
+func (w *worker) record(tm *tag.Map, ms ...Measurement) {
+	cmd := &recordReq{
+		now: time.Now(),
+		tm:  tm,
+		ms:  ms,
+	}
+	cmd.handleCommand(w)
 }
@@ -16,6 +16,7 @@
 package stats
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -212,20 +213,70 @@ type recordReq struct {
 
 func (cmd *recordReq) handleCommand(w *worker) {
 	for _, m := range cmd.ms {
+		var (
+			measure Measure
+			views   map[*View]bool
+			val     interface{}
+		)
 		switch measurement := m.(type) {
 		case *measurementFloat64:
-			for v := range measurement.m.views {
-				v.addSample(cmd.tm, measurement.v, cmd.now)
-			}
+			measure, views, val = measurement.m, measurement.m.views, measurement.v
 		case *measurementInt64:
-			for v := range measurement.m.views {
-				v.addSample(cmd.tm, measurement.v, cmd.now)
-			}
+			measure, views, val = measurement.m, measurement.m.views, measurement.v
 		default:
+			continue
+		}
+		if len(views) == 0 || !measure.isEnabled() {
+			continue
+		}
+		tm := cmd.tm
+		if f, ok := w.filters[measure]; ok {
+			var keep bool
+			if val, tm, keep = applyFilter(f, measure, val, tm); !keep {
+				continue
+			}
+		}
+		for v := range views {
+			v.addSample(tm, val, cmd.now)
 		}
 	}
 }
 
+// applyFilter applies f to a measurement of m, checking that the
+// rewritten value has the type of the values of m.
+func applyFilter(f RecordFilter, m Measure, val interface{}, tm *tag.Map) (interface{}, *tag.Map, bool) {
+	val, tm, keep := f(m, val, tm)
+	if !keep {
+		return nil, nil, false
+	}
+	switch m.(type) {
+	case *MeasureInt64:
+		_, keep = val.(int64)
+	case *MeasureFloat64:
+		_, keep = val.(float64)
+	}
+	if tm == nil {
+		tm = tag.FromContext(context.Background())
+	}
+	return val, tm, keep
+}
+
+// setRecordFilterReq is the command to set the filter of a measure.
+type setRecordFilterReq struct {
+	m Measure
+	f RecordFilter
+	c chan bool
+}
+
+func (cmd *setRecordFilterReq) handleCommand(w *worker) {
+	if cmd.f == nil {
+		delete(w.filters, cmd.m)
+	} else {
+		w.filters[cmd.m] = cmd.f
+	}
+	cmd.c <- true
+}
+
 // setReportingPeriodReq is the command to modify the duration between
 // reporting the collected data to the subscribed clients.
 type setReportingPeriodReq struct {
@@ -242,3 +293,33 @@ func (cmd *setReportingPeriodReq) handleCommand(w *worker) {
 	}
 	cmd.c <- true
 }
+
+// reportReq is the command to report the collected data to the exporters
+// immediately. If stop is set, periodic reporting is stopped.
+type reportReq struct {
+	now  time.Time
+	stop bool
+	c    chan bool
+}
+
+func (cmd *reportReq) handleCommand(w *worker) {
+	w.reportUsage(cmd.now)
+	if cmd.stop {
+		w.timer.Stop()
+	}
+	cmd.c <- true
+}
+
+// setMaxRowsReq is the command to limit the number of rows of a view.
+type setMaxRowsReq struct {
+	v *View
+	n int
+	c chan bool
+}
+
+func (cmd *setMaxRowsReq) handleCommand(w *worker) {
+	c := cmd.v.collector
+	c.maxRows = cmd.n
+	c.overflowSig = overflowSignature(cmd.v.tagKeys)
+	cmd.c <- true
+}
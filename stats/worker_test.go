@@ -612,7 +612,347 @@ func Test_Worker_RecordFloat64(t *testing.T) {
 	}
 }
 
+func Test_Worker_MeasureEnabled(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureInt64("MI1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureInt64() = %v", err)
+	}
+	v, err := NewView("VI1", "", nil, m, SumAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer v.Unsubscribe()
+
+	if !IsMeasureEnabled(m) {
+		t.Errorf("IsMeasureEnabled() = false for a new measure; want true")
+	}
+	Record(context.Background(), m.M(1))
+	SetMeasureEnabled(m, false)
+	if IsMeasureEnabled(m) {
+		t.Errorf("IsMeasureEnabled() = true after SetMeasureEnabled(false); want false")
+	}
+	Record(context.Background(), m.M(10))
+	SetMeasureEnabled(m, true)
+	Record(context.Background(), m.M(100))
+
+	rows, err := v.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("RetrieveData() = %v; want one row", rows)
+	}
+	if got, want := float64(*rows[0].Data.(*SumData)), 101.0; got != want {
+		t.Errorf("got sum %v; want %v", got, want)
+	}
+}
+
+func Test_Worker_RecordFilter(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureFloat64("MF1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureFloat64() = %v", err)
+	}
+	k1, _ := tag.NewKey("k1")
+	k2, _ := tag.NewKey("k2")
+	v, err := NewView("VF1", "", []tag.Key{k1, k2}, m, SumAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer v.Unsubscribe()
+
+	// The filter drops NaNs, clamps negative values to zero, drops
+	// k2, and rejects values rewritten to a wrong type.
+	SetRecordFilter(m, func(m Measure, value interface{}, tags *tag.Map) (interface{}, *tag.Map, bool) {
+		f := value.(float64)
+		switch {
+		case f != f:
+			return nil, nil, false
+		case f == 1000:
+			return int64(1), tags, true
+		case f < 0:
+			f = 0
+		}
+		tags, err := tag.NewMap(tag.NewContext(context.Background(), tags), tag.Delete(k2))
+		if err != nil {
+			return nil, nil, false
+		}
+		return f, tags, true
+	})
+	defer SetRecordFilter(m, nil)
+
+	ts, err := tag.NewMap(context.Background(), tag.Insert(k1, "v1"), tag.Insert(k2, "v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := tag.NewContext(context.Background(), ts)
+	nan := 0.0
+	Record(ctx, m.M(1), m.M(-5), m.M(nan/nan), m.M(1000), m.M(2))
+
+	want := &Row{[]tag.Tag{{Key: k1, Value: "v1"}}, newSumData(3)}
+	rows, err := v.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].Equal(want) {
+		t.Errorf("RetrieveData() = %v; want %v", rows, want)
+	}
+
+	SetRecordFilter(m, nil)
+	Record(ctx, m.M(-5))
+	rows, err = v.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("RetrieveData() = %v; want 2 rows after removing the filter", rows)
+	}
+}
+
+func Test_Worker_ViewMaxRows(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureInt64("MI1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureInt64() = %v", err)
+	}
+	k1, _ := tag.NewKey("k1")
+	v, err := NewView("VI1", "", []tag.Key{k1}, m, CountAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	v.SetMaxRows(2)
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer v.Unsubscribe()
+	ov, err := NewView("overflow", "", []tag.Key{ViewKey}, ViewOverflowCount, SumAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := ov.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer ov.Unsubscribe()
+
+	for _, val := range []string{"a", "b", "c", "a", "d", "c"} {
+		ts, err := tag.NewMap(context.Background(), tag.Insert(k1, val))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Record(tag.NewContext(context.Background(), ts), m.M(1))
+	}
+
+	rows, err := v.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	for _, want := range []*Row{
+		{[]tag.Tag{{Key: k1, Value: "a"}}, newCountData(2)},
+		{[]tag.Tag{{Key: k1, Value: "b"}}, newCountData(1)},
+		{[]tag.Tag{{Key: k1, Value: OverflowTagValue}}, newCountData(3)},
+	} {
+		if !containsRow(rows, want) {
+			t.Errorf("RetrieveData() = %v; want row %v", rows, want)
+		}
+	}
+	if len(rows) != 3 {
+		t.Errorf("RetrieveData() = %v; want 3 rows", rows)
+	}
+
+	Flush()
+	rows, err = ov.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	want := &Row{[]tag.Tag{{Key: ViewKey, Value: "VI1"}}, newSumData(3)}
+	if len(rows) != 1 || !rows[0].Equal(want) {
+		t.Errorf("overflow count = %v; want %v", rows, want)
+	}
+}
+
+type flushCloseExporter struct {
+	vds             []*ViewData
+	flushed, closed int
+}
+
+func (e *flushCloseExporter) Export(vd *ViewData) { e.vds = append(e.vds, vd) }
+
+func (e *flushCloseExporter) Flush() { e.flushed++ }
+
+func (e *flushCloseExporter) Close() error {
+	e.closed++
+	return nil
+}
+
+func Test_Worker_FlushAndShutdown(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureInt64("MS/m1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureInt64() = %v", err)
+	}
+	v, err := NewView("VF1", "", nil, m, CountAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	Record(context.Background(), m.M(1))
+
+	e := &flushCloseExporter{}
+	RegisterExporter(e)
+
+	Flush()
+	if got, want := len(e.vds), 1; got != want {
+		t.Errorf("after Flush: got %d view data; want %d", got, want)
+	}
+	if e.flushed != 1 || e.closed != 0 {
+		t.Errorf("after Flush: flushed %d times, closed %d times; want 1, 0", e.flushed, e.closed)
+	}
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if got, want := len(e.vds), 2; got != want {
+		t.Errorf("after Shutdown: got %d view data; want %d", got, want)
+	}
+	if e.flushed != 2 || e.closed != 1 {
+		t.Errorf("after Shutdown: flushed %d times, closed %d times; want 2, 1", e.flushed, e.closed)
+	}
+
+	Flush()
+	if got, want := len(e.vds), 2; got != want {
+		t.Errorf("exporter was called after Shutdown; got %d view data, want %d", got, want)
+	}
+}
+
 // restart stops the current processors and creates a new one.
+func Test_Worker_Observability(t *testing.T) {
+	restart()
+
+	e := &flushCloseExporter{}
+	RegisterExporter(e)
+	defer UnregisterExporter(e)
+	views := []*View{WorkerQueueLatencyView, RecordCountView, ViewCountView, ViewRowCountView, ExportLatencyView}
+	for _, v := range views {
+		if err := v.Subscribe(); err != nil {
+			t.Fatalf("Subscribe() = %v", err)
+		}
+		defer v.Unsubscribe()
+	}
+
+	m, err := NewMeasureInt64("MO/m1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureInt64() = %v", err)
+	}
+	v, err := NewView("VO1", "", nil, m, CountAggregation{}, Cumulative{})
+	if err != nil {
+		t.Fatalf("NewView() = %v", err)
+	}
+	if err := v.Subscribe(); err != nil {
+		t.Fatalf("Subscribe() = %v", err)
+	}
+	defer v.Unsubscribe()
+	for i := 0; i < 3; i++ {
+		Record(context.Background(), m.M(1))
+	}
+
+	rows, err := WorkerQueueLatencyView.RetrieveData()
+	if err != nil {
+		t.Fatalf("RetrieveData() = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Data.(*DistributionData).Count != 3 {
+		t.Errorf("worker queue latency = %v; want 3 data points", rows)
+	}
+
+	exported := func(v *View) []*Row {
+		for _, vd := range e.vds {
+			if vd.View == v {
+				return vd.Rows
+			}
+		}
+		t.Fatalf("view %q was not exported", v.Name())
+		return nil
+	}
+	Flush()
+	if rows, want := exported(RecordCountView), newSumData(3); len(rows) != 1 || !rows[0].Data.equal(want) {
+		t.Errorf("record count = %v; want %v", rows, want)
+	}
+	if rows := exported(ViewCountView); len(rows) != 1 || rows[0].Data.(*MeanData).Mean != float64(len(views)+1) {
+		t.Errorf("view count = %v; want %d", rows, len(views)+1)
+	}
+	wantRow := &Row{[]tag.Tag{{Key: ViewKey, Value: "VO1"}}, &MeanData{Count: 1, Mean: 1}}
+	if rows := exported(ViewRowCountView); !containsRow(rows, wantRow) {
+		t.Errorf("view row count = %v; want row %v", rows, wantRow)
+	}
+
+	// The latencies of the first exports are recorded by the next report.
+	n := len(e.vds)
+	e.vds = nil
+	Flush()
+	rows = exported(ExportLatencyView)
+	if len(rows) != 1 || rows[0].Tags[0].Value != "*stats.flushCloseExporter" || rows[0].Data.(*DistributionData).Count != int64(n) {
+		t.Errorf("export latency = %v; want %d data points for *stats.flushCloseExporter", rows, n)
+	}
+}
+
+// recordingExporter records a measurement for each view data it exports.
+type recordingExporter struct {
+	m *MeasureInt64
+}
+
+func (e *recordingExporter) Export(vd *ViewData) {
+	Record(context.Background(), e.m.M(1))
+}
+
+func Test_Worker_BlockingExporterRecords(t *testing.T) {
+	restart()
+
+	m, err := NewMeasureInt64("MB/m1", "", "")
+	if err != nil {
+		t.Fatalf("NewMeasureInt64() = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		v, err := NewView(fmt.Sprintf("VB%d", i), "", nil, m, CountAggregation{}, Cumulative{})
+		if err != nil {
+			t.Fatalf("NewView() = %v", err)
+		}
+		if err := v.Subscribe(); err != nil {
+			t.Fatalf("Subscribe() = %v", err)
+		}
+		defer v.Unsubscribe()
+	}
+	Record(context.Background(), m.M(1))
+
+	e := &recordingExporter{m: m}
+	RegisterExporterWithOptions(e, ExporterOptions{QueueSize: 1, DropPolicy: Block})
+	defer UnregisterExporter(e)
+
+	// The exporter blocks recording until the worker is done reporting,
+	// while the worker waits for room in its queue.
+	done := make(chan bool)
+	go func() {
+		Flush()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Flush() is blocked")
+	}
+}
+
 func restart() {
 	defaultWorker.stop()
 	defaultWorker = newWorker()
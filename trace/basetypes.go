@@ -44,7 +44,8 @@ type Annotation struct {
 }
 
 // Attribute is an interface for attributes;
-// it is implemented by BoolAttribute, IntAttribute, and StringAttribute.
+// it is implemented by BoolAttribute, Int64Attribute, Float64Attribute,
+// StringAttribute, and their array variants.
 type Attribute interface {
 	isAttribute()
 }
@@ -73,6 +74,46 @@ type StringAttribute struct {
 
 func (s StringAttribute) isAttribute() {}
 
+// Float64Attribute represents a float64-valued attribute.
+type Float64Attribute struct {
+	Key   string
+	Value float64
+}
+
+func (f Float64Attribute) isAttribute() {}
+
+// BoolArrayAttribute represents an attribute whose value is an array of bools.
+type BoolArrayAttribute struct {
+	Key   string
+	Value []bool
+}
+
+func (b BoolArrayAttribute) isAttribute() {}
+
+// Int64ArrayAttribute represents an attribute whose value is an array of int64s.
+type Int64ArrayAttribute struct {
+	Key   string
+	Value []int64
+}
+
+func (i Int64ArrayAttribute) isAttribute() {}
+
+// Float64ArrayAttribute represents an attribute whose value is an array of float64s.
+type Float64ArrayAttribute struct {
+	Key   string
+	Value []float64
+}
+
+func (f Float64ArrayAttribute) isAttribute() {}
+
+// StringArrayAttribute represents an attribute whose value is an array of strings.
+type StringArrayAttribute struct {
+	Key   string
+	Value []string
+}
+
+func (s StringArrayAttribute) isAttribute() {}
+
 // LinkType specifies the relationship between the span that had the link
 // added, and the linked span.
 type LinkType int32
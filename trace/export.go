@@ -15,43 +15,250 @@
 package trace
 
 import (
+	"context"
+	"io"
+	"log"
 	"sync"
 	"time"
+
+	"go.opencensus.io/internal/queue"
+	"go.opencensus.io/stats"
 )
 
 // Exporter is a type for functions that receive sampled trace spans.
 //
-// The Export method should return quickly; if an Exporter takes a significant
-// amount of time to process a SpanData, that work should be done on another
-// goroutine.
+// Each Exporter is called from its own goroutine, one span at a time, so
+// that a slow Exporter doesn't delay the others or the code ending spans.
 //
 // The SpanData should not be modified, but a pointer to it can be kept.
 type Exporter interface {
 	Export(s *SpanData)
 }
 
+// Flusher is an optional interface for exporters that buffer spans.
+// Flush should return once all the spans passed to Export have been
+// processed.
+//
+// Exporters that hold resources can also implement io.Closer; Close is
+// called on Shutdown after the exporter is flushed.
+type Flusher interface {
+	Flush()
+}
+
+// DropPolicy determines what happens to spans ended
+// while an Exporter's queue is full.
+type DropPolicy int
+
+const (
+	// DropNewest drops the span being ended.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest span in the queue.
+	DropOldest
+	// Block blocks ending spans until there is room in the queue.
+	Block
+)
+
+const defaultExportQueueSize = 2048
+
+// ExporterOptions configures how spans are handed to an Exporter.
+type ExporterOptions struct {
+	// QueueSize is the number of spans that can be waiting to be
+	// exported. If zero, a default size is used.
+	QueueSize int
+
+	// DropPolicy determines what happens when the queue is full.
+	// The default is DropNewest.
+	DropPolicy DropPolicy
+}
+
+// The following measures are recorded about the Exporters' queues. They are
+// tagged with stats.ExporterKey, whose value is the Go type of the Exporter.
 var (
-	exportersMu sync.Mutex
-	exporters   map[Exporter]struct{}
+	// ExportQueueDepth is the number of spans waiting to be exported,
+	// recorded when spans end, at most once per second for each Exporter,
+	// and when it is flushed or unregistered.
+	ExportQueueDepth *stats.MeasureInt64
+	// ExportDroppedCount is the number of spans dropped because
+	// an Exporter's queue was full. Exporters that buffer spans also
+	// record it when they drop spans themselves.
+	ExportDroppedCount *stats.MeasureInt64
+	// ExportLatency is the time in milliseconds an Exporter took to
+	// export one span. The latencies are buffered and recorded along
+	// with ExportQueueDepth.
+	ExportLatency *stats.MeasureFloat64
 )
 
+func init() {
+	var err error
+	if ExportQueueDepth, err = stats.NewMeasureInt64("/opencensus.io/trace/export_queue_depth", "Spans waiting to be exported", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/trace/export_queue_depth: %v", err)
+	}
+	if ExportDroppedCount, err = stats.NewMeasureInt64("/opencensus.io/trace/export_dropped_count", "Spans dropped by an exporter or because its queue was full", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/trace/export_dropped_count: %v", err)
+	}
+	if ExportLatency, err = stats.NewMeasureFloat64("/opencensus.io/trace/export_latency", "Time taken by an exporter to export a span", "ms"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/trace/export_latency: %v", err)
+	}
+	createObservabilityMeasures()
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   map[Exporter]*exportQueue
+)
+
+// exportQueue hands spans to an Exporter on its own goroutine.
+type exportQueue struct {
+	q   *queue.Queue
+	ctx context.Context // carries the tags recorded with the queue's measurements
+
+	mu         sync.Mutex
+	latencies  []float64 // export latencies in milliseconds, not recorded yet
+	lastRecord time.Time
+}
+
+func newExportQueue(e Exporter, o ExporterOptions) *exportQueue {
+	size := o.QueueSize
+	if size <= 0 {
+		size = defaultExportQueueSize
+	}
+	eq := &exportQueue{ctx: stats.ExporterContext(context.Background(), e)}
+	eq.q = queue.New(size, queue.DropPolicy(o.DropPolicy), func(item interface{}) {
+		start := time.Now()
+		e.Export(item.(*SpanData))
+		eq.addExportLatency(time.Since(start))
+	})
+	return eq
+}
+
 // RegisterExporter adds to the list of Exporters that will receive sampled
-// trace spans.
+// trace spans, using the default options.
 func RegisterExporter(e Exporter) {
+	RegisterExporterWithOptions(e, ExporterOptions{})
+}
+
+// RegisterExporterWithOptions adds to the list of Exporters that will
+// receive sampled trace spans an Exporter that is handed spans according
+// to o. If e is already registered, its options are replaced once the
+// spans already queued are exported.
+func RegisterExporterWithOptions(e Exporter, o ExporterOptions) {
+	eq := newExportQueue(e, o)
 	exportersMu.Lock()
 	if exporters == nil {
-		exporters = make(map[Exporter]struct{})
+		exporters = make(map[Exporter]*exportQueue)
 	}
-	exporters[e] = struct{}{}
+	old := exporters[e]
+	exporters[e] = eq
 	exportersMu.Unlock()
+
+	if old != nil {
+		old.q.Close()
+	}
 }
 
 // UnregisterExporter removes from the list of Exporters the Exporter that was
-// registered with the given name.
+// registered with the given name. The spans already queued for the Exporter
+// are exported and, if the Exporter implements Flusher, it is flushed before
+// UnregisterExporter returns.
 func UnregisterExporter(e Exporter) {
 	exportersMu.Lock()
+	eq := exporters[e]
 	delete(exporters, e)
 	exportersMu.Unlock()
+
+	if eq == nil {
+		return
+	}
+	eq.q.Close()
+	eq.recordStats()
+	if f, ok := e.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Flush waits for the spans already ended to be exported, and then flushes
+// all the registered Exporters that implement Flusher. If tail sampling is
+// enabled, the buffered traces are decided first.
+func Flush() {
+	flushTailSampling()
+	exportersMu.RLock()
+	es := make(map[Exporter]*exportQueue, len(exporters))
+	for e, eq := range exporters {
+		es[e] = eq
+	}
+	exportersMu.RUnlock()
+
+	for e, eq := range es {
+		eq.q.Flush()
+		eq.recordStats()
+		if f, ok := e.(Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// Shutdown unregisters all the Exporters, flushing the ones that implement
+// Flusher and closing the ones that implement io.Closer. Spans ended after
+// Shutdown are not exported. It returns ctx.Err() if ctx is done before the
+// Exporters are, or the first error returned by Close otherwise.
+func Shutdown(ctx context.Context) error {
+	flushTailSampling()
+	exportersMu.Lock()
+	es := exporters
+	exporters = nil
+	exportersMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for e, eq := range es {
+			eq.q.Close()
+			if f, ok := e.(Flusher); ok {
+				f.Flush()
+			}
+			if c, ok := e.(io.Closer); ok {
+				if cerr := c.Close(); err == nil {
+					err = cerr
+				}
+			}
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// exportSpan queues sd for all the registered Exporters. The measures
+// about the queues are recorded from here rather than from the queues'
+// goroutines so that they are still recorded when an Exporter is stuck.
+func exportSpan(sd *SpanData) {
+	now := time.Now()
+	var drops map[*exportQueue]int
+	var due []*exportQueue
+	exportersMu.RLock()
+	for _, eq := range exporters {
+		if n := eq.q.Add(sd); n > 0 {
+			if drops == nil {
+				drops = make(map[*exportQueue]int)
+			}
+			drops[eq] = n
+		}
+		if eq.statsDue(now) {
+			due = append(due, eq)
+		}
+	}
+	exportersMu.RUnlock()
+
+	for eq, n := range drops {
+		stats.Record(eq.ctx, ExportDroppedCount.M(int64(n)))
+	}
+	for _, eq := range due {
+		eq.recordStats()
+	}
 }
 
 // SpanData contains all the information collected by a Span.
@@ -61,7 +268,8 @@ type SpanData struct {
 	Name         string
 	StartTime    time.Time
 	EndTime      time.Time
-	// The values of Attributes each have type string, bool, or int64.
+	// The values of Attributes each have type string, bool, int64, float64,
+	// or a slice of one of those types.
 	Attributes    map[string]interface{}
 	Annotations   []Annotation
 	MessageEvents []MessageEvent
@@ -0,0 +1,125 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var (
+	// ActiveSpanCount is the number of active spans kept for a span name
+	// in the local span store, tagged with SpanNameKey. It is recorded at
+	// most once per second for each span name, when its spans start or end.
+	ActiveSpanCount *stats.MeasureInt64
+
+	SpanNameKey tag.Key
+)
+
+// The following views aggregate the measures recorded about the trace
+// library. They are not subscribed by default.
+var (
+	ActiveSpanCountView    *stats.View
+	ExportQueueDepthView   *stats.View
+	ExportDroppedCountView *stats.View
+	ExportLatencyView      *stats.View
+
+	// ObservabilityViews contains all the views above.
+	ObservabilityViews []*stats.View
+)
+
+var exportLatencyBucketBoundaries = []float64{0, 0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 5000, 10000}
+
+// createObservabilityMeasures creates the measures and views about the
+// trace library. It is called once the export measures are created.
+func createObservabilityMeasures() {
+	var err error
+	if SpanNameKey, err = tag.NewKey("opencensus.span_name"); err != nil {
+		log.Fatalf("Cannot create opencensus.span_name key: %v", err)
+	}
+	if ActiveSpanCount, err = stats.NewMeasureInt64("/opencensus.io/trace/active_spans", "Active spans in the local span store", "1"); err != nil {
+		log.Fatalf("Cannot create measure /opencensus.io/trace/active_spans: %v", err)
+	}
+
+	windowMinute := stats.Interval{Duration: time.Minute, Intervals: 6}
+	ActiveSpanCountView, _ = stats.NewView("opencensus.io/trace/active_spans/minute", "Active spans in the local span store for each span name", []tag.Key{SpanNameKey}, ActiveSpanCount, stats.MeanAggregation{}, windowMinute)
+	ExportQueueDepthView, _ = stats.NewView("opencensus.io/trace/export_queue_depth/minute", "Spans waiting to be exported by each exporter", []tag.Key{stats.ExporterKey}, ExportQueueDepth, stats.MeanAggregation{}, windowMinute)
+	ExportDroppedCountView, _ = stats.NewView("opencensus.io/trace/export_dropped_count/cumulative", "Spans dropped by each exporter", []tag.Key{stats.ExporterKey}, ExportDroppedCount, stats.SumAggregation{}, stats.Cumulative{})
+	ExportLatencyView, _ = stats.NewView("opencensus.io/trace/export_latency/distribution_cumulative", "Time taken by each exporter to export a span", []tag.Key{stats.ExporterKey}, ExportLatency, stats.DistributionAggregation(exportLatencyBucketBoundaries), stats.Cumulative{})
+
+	ObservabilityViews = []*stats.View{
+		ActiveSpanCountView,
+		ExportQueueDepthView,
+		ExportDroppedCountView,
+		ExportLatencyView,
+	}
+}
+
+// spanNameContext returns a context whose tags contain SpanNameKey with
+// the value name, or nil if name is not a valid tag value.
+func spanNameContext(name string) context.Context {
+	tags, err := tag.NewMap(context.Background(), tag.Insert(SpanNameKey, name))
+	if err != nil {
+		return nil
+	}
+	return tag.NewContext(context.Background(), tags)
+}
+
+// maxExportLatencies is the maximum number of export latencies kept for
+// an Exporter between two recordings. Latencies beyond it are not recorded.
+const maxExportLatencies = 4096
+
+// addExportLatency keeps the time taken to export one span until the
+// queue's measures are next recorded, so that exporting a span doesn't
+// wait on the stats worker.
+func (eq *exportQueue) addExportLatency(d time.Duration) {
+	eq.mu.Lock()
+	if len(eq.latencies) < maxExportLatencies {
+		eq.latencies = append(eq.latencies, float64(d)/float64(time.Millisecond))
+	}
+	eq.mu.Unlock()
+}
+
+// statsDue reports whether the measures about the queue are to be
+// recorded, which they are at most once per second.
+func (eq *exportQueue) statsDue(now time.Time) bool {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	if now.Sub(eq.lastRecord) < time.Second {
+		return false
+	}
+	eq.lastRecord = now
+	return true
+}
+
+// recordStats records the depth of the queue and the export latencies
+// kept since they were last recorded.
+func (eq *exportQueue) recordStats() {
+	eq.mu.Lock()
+	l := eq.latencies
+	eq.latencies = nil
+	eq.mu.Unlock()
+
+	ms := make([]stats.Measurement, 0, len(l)+1)
+	ms = append(ms, ExportQueueDepth.M(int64(eq.q.Len())))
+	for _, d := range l {
+		ms = append(ms, ExportLatency.M(d))
+	}
+	stats.Record(eq.ctx, ms...)
+}
@@ -32,6 +32,8 @@ package propagation
 // TraceId: (field_id = 0, len = 16, default = "0000000000000000") - 16-byte array representing the trace_id.
 // SpanId: (field_id = 1, len = 8, default = "00000000") - 8-byte array representing the span_id.
 // TraceOptions: (field_id = 2, len = 1, default = "0") - 1-byte array representing the trace_options.
+// Bit 0 of trace_options is set if the trace is sampled, and bit 1 if it is
+// forced to be sampled for debugging.
 //
 // Fields MUST be encoded using the field id order (smaller to higher).
 //
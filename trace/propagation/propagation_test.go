@@ -117,6 +117,21 @@ func TestFromBinary(t *testing.T) {
 	}
 }
 
+func TestBinaryDebug(t *testing.T) {
+	sc := SpanContext{
+		TraceID:      TraceID{1},
+		SpanID:       SpanID{2},
+		TraceOptions: 3,
+	}
+	got, ok := FromBinary(Binary(sc))
+	if !ok {
+		t.Fatal("FromBinary: got ok==false, want true")
+	}
+	if !got.IsDebug() || !got.IsSampled() {
+		t.Errorf("FromBinary: got TraceOptions %x, want sampled and debug", got.TraceOptions)
+	}
+}
+
 func BenchmarkBinary(b *testing.B) {
 	tid := TraceID{0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49, 0x4a, 0x4b, 0x4c, 0x4d, 0x4e, 0x4f}
 	sid := SpanID{0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68}
@@ -16,6 +16,8 @@ package trace
 
 import (
 	"encoding/binary"
+	"sync"
+	"time"
 )
 
 const defaultSamplingProbability = 1e-4
@@ -38,6 +40,10 @@ func SetDefaultSampler(sampler Sampler) {
 
 // Sampler is an interface for values that have a method that the trace library
 // can call to determine whether to export a trace's spans.
+//
+// Samplers are not consulted for the spans of traces with the debug
+// TraceOptions bit set, which are always sampled; the built-in Samplers
+// also sample spans whose parent has the bit set when called directly.
 type Sampler interface {
 	Sample(p SamplingParameters) SamplingDecision
 }
@@ -49,6 +55,7 @@ type SamplingParameters struct {
 	SpanID
 	Name            string
 	HasRemoteParent bool
+	Links           []Link // the links of StartSpanOptions
 }
 
 // SamplingDecision is the value returned by a Sampler.
@@ -58,7 +65,7 @@ type SamplingDecision struct {
 
 // ProbabilitySampler returns a Sampler that samples a given fraction of traces.
 //
-// It also samples spans whose parents are sampled.
+// It also samples spans whose parents are sampled or have the debug bit set.
 func ProbabilitySampler(fraction float64) Sampler {
 	if !(fraction >= 0) {
 		fraction = 0
@@ -77,7 +84,7 @@ type probabilitySampler struct {
 var _ Sampler = (*probabilitySampler)(nil)
 
 func (s probabilitySampler) Sample(p SamplingParameters) (d SamplingDecision) {
-	if p.ParentContext.IsSampled() {
+	if p.ParentContext.IsSampled() || p.ParentContext.IsDebug() {
 		return SamplingDecision{Sample: true}
 	}
 	x := binary.BigEndian.Uint64(p.TraceID[0:8]) >> 1
@@ -97,7 +104,8 @@ func (a always) Sample(p SamplingParameters) SamplingDecision {
 	return SamplingDecision{Sample: true}
 }
 
-// NeverSample returns a Sampler that samples no traces.
+// NeverSample returns a Sampler that samples no traces,
+// except those with the debug bit set in the parent's TraceOptions.
 func NeverSample() Sampler {
 	return never{}
 }
@@ -107,5 +115,172 @@ type never struct{}
 var _ Sampler = never{}
 
 func (n never) Sample(p SamplingParameters) SamplingDecision {
-	return SamplingDecision{Sample: false}
+	return SamplingDecision{Sample: p.ParentContext.IsDebug()}
+}
+
+// RateLimitingSampler returns a Sampler that samples at most tracesPerSecond
+// new traces per second, allowing bursts of up to one second's worth of
+// traces.
+//
+// It also samples spans whose parents are sampled or have the debug bit set,
+// without counting them against the limit.
+func RateLimitingSampler(tracesPerSecond float64) Sampler {
+	if !(tracesPerSecond > 0) {
+		return NeverSample()
+	}
+	return &rateLimitingSampler{
+		b:   newTokenBucket(tracesPerSecond, time.Now()),
+		now: time.Now,
+	}
+}
+
+type rateLimitingSampler struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	b  tokenBucket
+}
+
+var _ Sampler = (*rateLimitingSampler)(nil)
+
+func (s *rateLimitingSampler) Sample(p SamplingParameters) SamplingDecision {
+	if p.ParentContext.IsSampled() || p.ParentContext.IsDebug() {
+		return SamplingDecision{Sample: true}
+	}
+	s.mu.Lock()
+	ok := s.b.take(s.now())
+	s.mu.Unlock()
+	return SamplingDecision{Sample: ok}
+}
+
+// tokenBucket holds up to capacity tokens and is refilled
+// at rate tokens per second.
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) tokenBucket {
+	capacity := rate
+	if capacity < 1 {
+		capacity = 1
+	}
+	return tokenBucket{rate: rate, capacity: capacity, tokens: capacity, last: now}
+}
+
+// take refills the bucket for the time elapsed since the last call
+// and takes a token from it, if there is one.
+func (b *tokenBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+const (
+	// adaptiveWindow is the period over which the adaptive sampler
+	// measures the rate of each span name before adjusting its probability.
+	adaptiveWindow = 5 * time.Second
+
+	// maxAdaptiveNames is the maximum number of span names tracked
+	// separately by the adaptive sampler. Further names share a
+	// single budget.
+	maxAdaptiveNames = 1000
+)
+
+// AdaptiveSampler returns a Sampler that targets tracesPerSecond sampled
+// traces per second for each span name. The probability of sampling a name
+// is adjusted periodically from the rate at which spans with that name
+// start, so that low-traffic operations are always sampled while
+// high-traffic operations are sampled only as much as needed to reach
+// the target. The number of traces sampled for a name never exceeds the
+// target over a measurement period.
+//
+// It also samples spans whose parents are sampled or have the debug bit set,
+// without counting them against the target.
+func AdaptiveSampler(tracesPerSecond float64) Sampler {
+	if !(tracesPerSecond > 0) {
+		return NeverSample()
+	}
+	return &adaptiveSampler{
+		target: tracesPerSecond,
+		names:  make(map[string]*nameRate),
+		now:    time.Now,
+	}
+}
+
+type adaptiveSampler struct {
+	target float64 // traces per second per span name
+	now    func() time.Time
+
+	mu    sync.Mutex
+	names map[string]*nameRate
+	other *nameRate // shared by the names beyond maxAdaptiveNames
+}
+
+// nameRate tracks the spans seen and sampled for a span name
+// during the current window.
+type nameRate struct {
+	start       time.Time
+	seen        int
+	sampled     int
+	probability float64
+}
+
+var _ Sampler = (*adaptiveSampler)(nil)
+
+func (s *adaptiveSampler) Sample(p SamplingParameters) SamplingDecision {
+	if p.ParentContext.IsSampled() || p.ParentContext.IsDebug() {
+		return SamplingDecision{Sample: true}
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.rate(p.Name, now)
+	if elapsed := now.Sub(r.start); elapsed >= adaptiveWindow {
+		if r.seen > 0 {
+			r.probability = s.target / (float64(r.seen) / elapsed.Seconds())
+			if r.probability > 1 {
+				r.probability = 1
+			}
+		}
+		r.start, r.seen, r.sampled = now, 0, 0
+	}
+	r.seen++
+	if float64(r.sampled) >= s.target*adaptiveWindow.Seconds() {
+		return SamplingDecision{Sample: false}
+	}
+	x := binary.BigEndian.Uint64(p.TraceID[0:8]) >> 1
+	if x >= uint64(r.probability*(1<<63)) && r.probability < 1 {
+		return SamplingDecision{Sample: false}
+	}
+	r.sampled++
+	return SamplingDecision{Sample: true}
+}
+
+// rate returns the nameRate for name, creating it if needed.
+func (s *adaptiveSampler) rate(name string, now time.Time) *nameRate {
+	if r, ok := s.names[name]; ok {
+		return r
+	}
+	if len(s.names) >= maxAdaptiveNames {
+		if s.other == nil {
+			s.other = &nameRate{start: now, probability: 1}
+		}
+		return s.other
+	}
+	r := &nameRate{start: now, probability: 1}
+	s.names[name] = r
+	return r
 }
@@ -0,0 +1,103 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// SamplingRule delegates the sampling decision for the spans it matches
+// to Sampler. A span matches a rule if it satisfies all of the rule's
+// non-empty conditions; a rule with no conditions matches every span.
+type SamplingRule struct {
+	// Name, if not empty, is a glob pattern the span name must match.
+	// The pattern syntax is that of path.Match, so * does not match /.
+	Name string
+
+	// NameRegexp, if not nil, must match the span name.
+	NameRegexp *regexp.Regexp
+
+	// HasRemoteParent, if not nil, must equal the HasRemoteParent
+	// sampling parameter.
+	HasRemoteParent *bool
+
+	// ParentSampled, if not nil, must equal whether the parent span
+	// is sampled.
+	ParentSampled *bool
+
+	// Sampler makes the sampling decision for the matched spans.
+	Sampler Sampler
+}
+
+func (r *SamplingRule) match(p SamplingParameters) bool {
+	if r.Name != "" {
+		if ok, _ := path.Match(r.Name, p.Name); !ok {
+			return false
+		}
+	}
+	if r.NameRegexp != nil && !r.NameRegexp.MatchString(p.Name) {
+		return false
+	}
+	if r.HasRemoteParent != nil && *r.HasRemoteParent != p.HasRemoteParent {
+		return false
+	}
+	if r.ParentSampled != nil && *r.ParentSampled != p.ParentContext.IsSampled() {
+		return false
+	}
+	return true
+}
+
+// RuleSampler returns a Sampler that applies the first of the rules matching
+// a span, in order, and falls back to fallback for spans matching none.
+// If fallback is nil, unmatched spans are sampled only if their parent is.
+// Spans whose parent has the debug bit set are always sampled.
+func RuleSampler(rules []SamplingRule, fallback Sampler) (Sampler, error) {
+	for i, r := range rules {
+		if r.Sampler == nil {
+			return nil, fmt.Errorf("trace: sampling rule %d has no sampler", i)
+		}
+		if _, err := path.Match(r.Name, ""); err != nil {
+			return nil, fmt.Errorf("trace: sampling rule %d: invalid name pattern %q", i, r.Name)
+		}
+	}
+	if fallback == nil {
+		fallback = ProbabilitySampler(0)
+	}
+	return &ruleSampler{
+		rules:    append([]SamplingRule(nil), rules...),
+		fallback: fallback,
+	}, nil
+}
+
+type ruleSampler struct {
+	rules    []SamplingRule
+	fallback Sampler
+}
+
+var _ Sampler = (*ruleSampler)(nil)
+
+func (s *ruleSampler) Sample(p SamplingParameters) SamplingDecision {
+	if p.ParentContext.IsDebug() {
+		return SamplingDecision{Sample: true}
+	}
+	for i := range s.rules {
+		if s.rules[i].match(p) {
+			return s.rules[i].Sampler.Sample(p)
+		}
+	}
+	return s.fallback.Sample(p)
+}
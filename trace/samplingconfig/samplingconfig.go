@@ -0,0 +1,157 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package samplingconfig builds trace samplers from JSON or YAML
+// configuration, so that sampling can be changed without code changes.
+//
+// A configuration lists rules, applied in order, and the sampler used
+// for the spans matching none of them:
+//
+//	rules:
+//	- name: /healthz
+//	  sampler: {type: never}
+//	- name_regexp: ^/admin\.
+//	  sampler: {type: always}
+//	default:
+//	  type: probability
+//	  fraction: 0.01
+//
+// The sampler types are always, never, probability (with fraction),
+// rate_limiting and adaptive (with traces_per_second).
+package samplingconfig // import "go.opencensus.io/trace/samplingconfig"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"go.opencensus.io/trace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is the sampling configuration.
+type Config struct {
+	// Rules are applied in order; the first rule matching
+	// a span makes its sampling decision.
+	Rules []Rule `yaml:"rules" json:"rules"`
+
+	// Default samples the spans matching none of the rules.
+	// If nil, they are sampled only if their parent is.
+	Default *Sampler `yaml:"default" json:"default"`
+}
+
+// Rule is the configuration of a trace.SamplingRule.
+type Rule struct {
+	Name            string  `yaml:"name" json:"name"`
+	NameRegexp      string  `yaml:"name_regexp" json:"name_regexp"`
+	HasRemoteParent *bool   `yaml:"has_remote_parent" json:"has_remote_parent"`
+	ParentSampled   *bool   `yaml:"parent_sampled" json:"parent_sampled"`
+	Sampler         Sampler `yaml:"sampler" json:"sampler"`
+}
+
+// Sampler is the configuration of one of the built-in samplers.
+type Sampler struct {
+	// Type is one of always, never, probability,
+	// rate_limiting and adaptive.
+	Type string `yaml:"type" json:"type"`
+
+	// Fraction is the fraction of traces sampled by
+	// the probability sampler.
+	Fraction float64 `yaml:"fraction" json:"fraction"`
+
+	// TracesPerSecond is the limit of the rate_limiting sampler
+	// and the per span name target of the adaptive sampler.
+	TracesPerSecond float64 `yaml:"traces_per_second" json:"traces_per_second"`
+}
+
+// Load reads the configuration in the named JSON or YAML file
+// and returns the sampler it describes.
+func Load(filename string) (trace.Sampler, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a JSON or YAML configuration and
+// returns the sampler it describes.
+func Parse(data []byte) (trace.Sampler, error) {
+	var c Config
+	// JSON is a subset of YAML, so a single decoder handles both.
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("samplingconfig: %v", err)
+	}
+	return c.Sampler()
+}
+
+// Sampler returns the sampler described by c.
+func (c *Config) Sampler() (trace.Sampler, error) {
+	rules := make([]trace.SamplingRule, len(c.Rules))
+	for i, r := range c.Rules {
+		s, err := r.Sampler.sampler()
+		if err != nil {
+			return nil, fmt.Errorf("samplingconfig: rule %d: %v", i, err)
+		}
+		rules[i] = trace.SamplingRule{
+			Name:            r.Name,
+			HasRemoteParent: r.HasRemoteParent,
+			ParentSampled:   r.ParentSampled,
+			Sampler:         s,
+		}
+		if r.NameRegexp != "" {
+			re, err := regexp.Compile(r.NameRegexp)
+			if err != nil {
+				return nil, fmt.Errorf("samplingconfig: rule %d: %v", i, err)
+			}
+			rules[i].NameRegexp = re
+		}
+	}
+	var fallback trace.Sampler
+	if c.Default != nil {
+		s, err := c.Default.sampler()
+		if err != nil {
+			return nil, fmt.Errorf("samplingconfig: default: %v", err)
+		}
+		fallback = s
+	}
+	return trace.RuleSampler(rules, fallback)
+}
+
+func (s *Sampler) sampler() (trace.Sampler, error) {
+	switch s.Type {
+	case "always":
+		return trace.AlwaysSample(), nil
+	case "never":
+		return trace.NeverSample(), nil
+	case "probability":
+		if s.Fraction < 0 || s.Fraction > 1 {
+			return nil, fmt.Errorf("fraction %v not in [0, 1]", s.Fraction)
+		}
+		return trace.ProbabilitySampler(s.Fraction), nil
+	case "rate_limiting":
+		if s.TracesPerSecond <= 0 {
+			return nil, fmt.Errorf("traces_per_second %v must be positive", s.TracesPerSecond)
+		}
+		return trace.RateLimitingSampler(s.TracesPerSecond), nil
+	case "adaptive":
+		if s.TracesPerSecond <= 0 {
+			return nil, fmt.Errorf("traces_per_second %v must be positive", s.TracesPerSecond)
+		}
+		return trace.AdaptiveSampler(s.TracesPerSecond), nil
+	case "":
+		return nil, fmt.Errorf("missing sampler type")
+	}
+	return nil, fmt.Errorf("unknown sampler type %q", s.Type)
+}
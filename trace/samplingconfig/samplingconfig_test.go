@@ -0,0 +1,85 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package samplingconfig
+
+import (
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+const yamlConfig = `
+rules:
+- name: /healthz
+  sampler: {type: never}
+- name_regexp: ^/admin\.
+  sampler: {type: always}
+- has_remote_parent: true
+  parent_sampled: true
+  sampler: {type: always}
+default:
+  type: never
+`
+
+const jsonConfig = `{
+  "rules": [
+    {"name": "/healthz", "sampler": {"type": "never"}},
+    {"name_regexp": "^/admin\\.", "sampler": {"type": "always"}},
+    {"has_remote_parent": true, "parent_sampled": true, "sampler": {"type": "always"}}
+  ],
+  "default": {"type": "never"}
+}`
+
+func TestParse(t *testing.T) {
+	sampled := trace.SpanContext{TraceOptions: 1}
+	tests := []struct {
+		p    trace.SamplingParameters
+		want bool
+	}{
+		{trace.SamplingParameters{Name: "/healthz"}, false},
+		{trace.SamplingParameters{Name: "/healthz", ParentContext: sampled}, false},
+		{trace.SamplingParameters{Name: "/admin.Users"}, true},
+		{trace.SamplingParameters{Name: "/api.Users"}, false},
+		{trace.SamplingParameters{Name: "/api.Users", HasRemoteParent: true}, false},
+		{trace.SamplingParameters{Name: "/api.Users", HasRemoteParent: true, ParentContext: sampled}, true},
+	}
+	for _, config := range []string{yamlConfig, jsonConfig} {
+		s, err := Parse([]byte(config))
+		if err != nil {
+			t.Fatalf("Parse() = %v", err)
+		}
+		for _, tt := range tests {
+			if got := s.Sample(tt.p).Sample; got != tt.want {
+				t.Errorf("%+v: got sampled %t, want %t", tt.p, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	for _, config := range []string{
+		`rules: [{name: /foo}]`,
+		`rules: [{name: "[", sampler: {type: always}}]`,
+		`rules: [{name_regexp: "(", sampler: {type: always}}]`,
+		`default: {type: sometimes}`,
+		`default: {type: probability, fraction: 2}`,
+		`default: {type: rate_limiting}`,
+		`default: {type: always, unknown: 1}`,
+	} {
+		if _, err := Parse([]byte(config)); err == nil {
+			t.Errorf("%q: got no error, want an error", config)
+		}
+	}
+}
@@ -15,8 +15,11 @@
 package trace
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"go.opencensus.io/stats"
 )
 
 const (
@@ -206,6 +209,9 @@ type spanStore struct {
 	errors                 map[int32]*bucket
 	latency                []bucket
 	maxSpansPerErrorBucket int
+
+	ctx        context.Context // carries the tags of ActiveSpanCount, if valid
+	lastActive time.Time       // when ActiveSpanCount was last recorded
 }
 
 // newSpanStore creates a span store.
@@ -214,6 +220,7 @@ func newSpanStore(name string, latencyBucketSize int, errorBucketSize int) *span
 		active:                 make(map[*Span]struct{}),
 		latency:                make([]bucket, len(defaultLatencies)+1),
 		maxSpansPerErrorBucket: errorBucketSize,
+		ctx:                    spanNameContext(name),
 	}
 	for i := range s.latency {
 		s.latency[i] = makeBucket(latencyBucketSize)
@@ -291,6 +298,31 @@ func (s *spanStore) resize(latencyBucketSize int, errorBucketSize int) {
 func (s *spanStore) add(span *Span) {
 	s.mu.Lock()
 	s.active[span] = struct{}{}
+	n, record := s.activeCount()
+	s.mu.Unlock()
+	if record {
+		stats.Record(s.ctx, ActiveSpanCount.M(n))
+	}
+}
+
+// activeCount returns the number of active spans and whether it is to be
+// recorded. s.mu must be held.
+func (s *spanStore) activeCount() (int64, bool) {
+	if s.ctx == nil {
+		return 0, false
+	}
+	now := time.Now()
+	if now.Sub(s.lastActive) < time.Second {
+		return 0, false
+	}
+	s.lastActive = now
+	return int64(len(s.active)), true
+}
+
+// discarded removes a span from the active set.
+func (s *spanStore) discarded(span *Span) {
+	s.mu.Lock()
+	delete(s.active, span)
 	s.mu.Unlock()
 }
 
@@ -319,5 +351,9 @@ func (s *spanStore) finished(span *Span, sd *SpanData) {
 			b.add(sd)
 		}
 	}
+	n, record := s.activeCount()
 	s.mu.Unlock()
+	if record {
+		stats.Record(s.ctx, ActiveSpanCount.M(n))
+	}
 }
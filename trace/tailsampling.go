@@ -0,0 +1,303 @@
+// Copyright 2017, OpenCensus Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDecisionWait = 5 * time.Second
+	defaultMaxRootWait  = 30 * time.Second
+	defaultMaxTailSpans = 10000
+)
+
+// TailPolicy reports whether a span makes its trace worth keeping.
+type TailPolicy func(sd *SpanData) bool
+
+// ErrorPolicy returns a TailPolicy keeping the traces
+// with a span whose Status has a non-zero code.
+func ErrorPolicy() TailPolicy {
+	return func(sd *SpanData) bool {
+		return sd.Status.Code != 0
+	}
+}
+
+// LatencyPolicy returns a TailPolicy keeping the traces
+// with a span lasting at least threshold.
+func LatencyPolicy(threshold time.Duration) TailPolicy {
+	return func(sd *SpanData) bool {
+		return sd.EndTime.Sub(sd.StartTime) >= threshold
+	}
+}
+
+// AttributePolicy returns a TailPolicy keeping the traces
+// with a span having the attribute key set to value.
+func AttributePolicy(key string, value interface{}) TailPolicy {
+	return func(sd *SpanData) bool {
+		v, ok := sd.Attributes[key]
+		return ok && reflect.DeepEqual(v, value)
+	}
+}
+
+// SampledPolicy returns a TailPolicy keeping the traces
+// with a span sampled by the head sampler.
+func SampledPolicy() TailPolicy {
+	return func(sd *SpanData) bool {
+		return sd.IsSampled()
+	}
+}
+
+// TailSamplingOptions configures tail sampling.
+type TailSamplingOptions struct {
+	// Policies decide which traces are kept. A trace is kept
+	// if any policy matches any of its spans.
+	Policies []TailPolicy
+
+	// DecisionWait is how long the spans of a trace are buffered after
+	// its local root span ends before the trace is kept or dropped.
+	// If zero, a default of 5 seconds is used.
+	DecisionWait time.Duration
+
+	// MaxRootWait is how long the spans of a trace are buffered after
+	// the first one ends if its local root span doesn't end, for instance
+	// because it doesn't record events. If it is less than DecisionWait,
+	// a default of 30 seconds or DecisionWait, whichever is longer, is used.
+	MaxRootWait time.Duration
+
+	// MaxSpans is the maximum number of spans buffered. When it is
+	// reached, the oldest traces are decided early to make room.
+	// If zero, a default of 10000 is used.
+	MaxSpans int
+}
+
+// EnableTailSampling inserts a tail sampling stage between the end of spans
+// and the registered Exporters.
+//
+// While enabled, every span recording events, whether or not it is sampled,
+// is buffered with the other local spans of its trace, and the whole trace
+// is exported or dropped according to o.Policies once o.DecisionWait has
+// passed since its local root span ended. Starting spans with RecordEvents
+// set, or with a sampler sampling more traces, lets the policies find rare
+// traces the head sampler would have missed. Spans of a trace ending after
+// its decision follow it, as long as it is among the most recent decisions.
+//
+// Calling EnableTailSampling again replaces the options; the traces
+// buffered until then are decided immediately.
+func EnableTailSampling(o TailSamplingOptions) {
+	if o.DecisionWait <= 0 {
+		o.DecisionWait = defaultDecisionWait
+	}
+	if o.MaxRootWait < o.DecisionWait {
+		o.MaxRootWait = defaultMaxRootWait
+		if o.MaxRootWait < o.DecisionWait {
+			o.MaxRootWait = o.DecisionWait
+		}
+	}
+	if o.MaxSpans <= 0 {
+		o.MaxSpans = defaultMaxTailSpans
+	}
+	ts := newTailSampler(o)
+	tailMu.Lock()
+	old := tail
+	tail = ts
+	tailMu.Unlock()
+	if old != nil {
+		old.stop()
+	}
+}
+
+// DisableTailSampling removes the tail sampling stage, deciding the traces
+// buffered until then. Afterwards, sampled spans are exported as they end.
+func DisableTailSampling() {
+	tailMu.Lock()
+	old := tail
+	tail = nil
+	tailMu.Unlock()
+	if old != nil {
+		old.stop()
+	}
+}
+
+var (
+	tailMu sync.RWMutex
+	tail   *tailSampler
+)
+
+// handleEndedSpan hands sd to the tail sampling stage, if enabled, and otherwise
+// exports it if it is sampled.
+func handleEndedSpan(sd *SpanData) {
+	tailMu.RLock()
+	ts := tail
+	tailMu.RUnlock()
+	if ts != nil {
+		ts.add(sd)
+	} else if sd.IsSampled() {
+		exportSpan(sd)
+	}
+}
+
+// flushTailSampling decides all the buffered traces.
+func flushTailSampling() {
+	tailMu.RLock()
+	ts := tail
+	tailMu.RUnlock()
+	if ts != nil {
+		ts.decide(func(*tailTrace) bool { return true })
+	}
+}
+
+type tailSampler struct {
+	o    TailSamplingOptions
+	done chan struct{}
+
+	mu     sync.Mutex
+	traces map[TraceID]*tailTrace
+	order  *list.List // of *tailTrace, oldest first
+	spans  int        // buffered spans
+
+	// decisions holds whether the most recently decided traces were
+	// kept, so that their spans ending late follow the same decision.
+	decisions     map[TraceID]bool
+	decisionOrder *list.List // of TraceID, oldest first
+}
+
+// tailTrace holds the buffered spans of a trace.
+type tailTrace struct {
+	id       TraceID
+	deadline time.Time
+	spans    []*SpanData
+	keep     bool
+	elem     *list.Element
+}
+
+func newTailSampler(o TailSamplingOptions) *tailSampler {
+	ts := &tailSampler{
+		o:      o,
+		done:   make(chan struct{}),
+		traces: make(map[TraceID]*tailTrace),
+		order:  list.New(),
+
+		decisions:     make(map[TraceID]bool),
+		decisionOrder: list.New(),
+	}
+	go ts.run()
+	return ts
+}
+
+func (ts *tailSampler) run() {
+	interval := ts.o.DecisionWait / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			ts.decide(func(t *tailTrace) bool { return !now.Before(t.deadline) })
+		case <-ts.done:
+			return
+		}
+	}
+}
+
+func (ts *tailSampler) add(sd *SpanData) {
+	var decided []*tailTrace
+	ts.mu.Lock()
+	t := ts.traces[sd.TraceID]
+	if t == nil {
+		if keep, ok := ts.decisions[sd.TraceID]; ok {
+			ts.mu.Unlock()
+			if keep {
+				exportSpan(sd)
+			}
+			return
+		}
+		t = &tailTrace{id: sd.TraceID, deadline: time.Now().Add(ts.o.MaxRootWait)}
+		t.elem = ts.order.PushBack(t)
+		ts.traces[sd.TraceID] = t
+	}
+	if sd.ParentSpanID == (SpanID{}) || sd.HasRemoteParent {
+		// The local root ended: the other local spans have
+		// DecisionWait to end.
+		t.deadline = time.Now().Add(ts.o.DecisionWait)
+	}
+	t.spans = append(t.spans, sd)
+	ts.spans++
+	if !t.keep {
+		for _, p := range ts.o.Policies {
+			if p(sd) {
+				t.keep = true
+				break
+			}
+		}
+	}
+	for ts.spans > ts.o.MaxSpans {
+		decided = append(decided, ts.remove(ts.order.Front().Value.(*tailTrace)))
+	}
+	ts.mu.Unlock()
+	exportKept(decided)
+}
+
+// decide removes from the buffer the traces, oldest first, for which
+// ready returns true, and exports the kept ones.
+func (ts *tailSampler) decide(ready func(*tailTrace) bool) {
+	var decided []*tailTrace
+	ts.mu.Lock()
+	for e := ts.order.Front(); e != nil; {
+		next := e.Next()
+		if t := e.Value.(*tailTrace); ready(t) {
+			decided = append(decided, ts.remove(t))
+		}
+		e = next
+	}
+	ts.mu.Unlock()
+	exportKept(decided)
+}
+
+// remove removes t from the buffer and remembers its decision.
+// It requires ts.mu to be held.
+func (ts *tailSampler) remove(t *tailTrace) *tailTrace {
+	ts.order.Remove(t.elem)
+	delete(ts.traces, t.id)
+	ts.spans -= len(t.spans)
+
+	ts.decisions[t.id] = t.keep
+	ts.decisionOrder.PushBack(t.id)
+	for ts.decisionOrder.Len() > ts.o.MaxSpans {
+		delete(ts.decisions, ts.decisionOrder.Remove(ts.decisionOrder.Front()).(TraceID))
+	}
+	return t
+}
+
+func (ts *tailSampler) stop() {
+	close(ts.done)
+	ts.decide(func(*tailTrace) bool { return true })
+}
+
+func exportKept(traces []*tailTrace) {
+	for _, t := range traces {
+		if !t.keep {
+			continue
+		}
+		for _, sd := range t.spans {
+			exportSpan(sd)
+		}
+	}
+}
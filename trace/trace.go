@@ -99,6 +99,17 @@ func (t TraceOptions) IsSampled() bool {
 	return t&1 == 1
 }
 
+// IsDebug returns true if the trace is forced to be sampled
+// by every process it is propagated to.
+func (t TraceOptions) IsDebug() bool {
+	return t&2 == 2
+}
+
+// setIsDebug sets the TraceOptions bit that forces the trace to be sampled.
+func (sc *SpanContext) setIsDebug() {
+	sc.TraceOptions |= 2
+}
+
 // SpanContext contains the state that must propagate across process boundaries.
 //
 // SpanContext is not an implementation of context.Context.
@@ -133,6 +144,28 @@ type StartSpanOptions struct {
 	// of this name should be created, if one does not exist.
 	// If RecordEvents is false, this option has no effect.
 	RegisterNameForLocalSpanStore bool
+	// Debug forces this span and its descendants to be sampled, including
+	// those in the processes the trace is propagated to, whatever their
+	// Samplers. Spans whose parent has this option set inherit it.
+	Debug bool
+	// StartTime, if non-zero, is the start time of the span, which allows
+	// creating spans for work that started earlier. Otherwise, the span
+	// starts at the current time.
+	StartTime time.Time
+	// Links are links from the span to other spans. They are passed to
+	// the Sampler, so that it can take the linked traces into account.
+	Links []Link
+}
+
+// EndOptions contains options concerning how a span is ended.
+type EndOptions struct {
+	// EndTime, if non-zero, is the end time of the span, which allows
+	// ending spans for work that finished earlier. Otherwise, the span
+	// ends at the current time.
+	EndTime time.Time
+	// Discard, if true, ends the span without exporting it or adding it
+	// to the local span store, for work that turned out not to be done.
+	Discard bool
 }
 
 // StartSpan starts a new child span of the current span in the context.
@@ -159,6 +192,23 @@ func StartSpanWithRemoteParent(ctx context.Context, name string, parent SpanCont
 	return WithSpan(ctx, NewSpanWithRemoteParent(name, parent, o))
 }
 
+// StartBatchSpan starts a new child span of the current span in the context,
+// for processing a batch of messages that carry the given remote SpanContexts.
+//
+// The new span cannot be the child of all of the remote spans, so instead it
+// has a link of type LinkTypeChild to each of them, in addition to o.Links.
+//
+// If there is no span in the context, creates a new trace and span.
+func StartBatchSpan(ctx context.Context, name string, remotes []SpanContext, o StartSpanOptions) context.Context {
+	parentSpan, _ := ctx.Value(contextKey{}).(*Span)
+	links := make([]Link, 0, len(remotes)+len(o.Links))
+	for _, sc := range remotes {
+		links = append(links, Link{TraceID: sc.TraceID, SpanID: sc.SpanID, Type: LinkTypeChild})
+	}
+	o.Links = append(links, o.Links...)
+	return WithSpan(ctx, parentSpan.StartSpanWithOptions(name, o))
+}
+
 // StartSpan starts a new child span.
 //
 // If s is nil, creates a new trace and span, like the function NewSpan.
@@ -199,7 +249,12 @@ func startSpanInternal(name string, hasParent bool, parent SpanContext, remotePa
 	sampler := defaultSampler
 	mu.Unlock()
 
-	if !hasParent || remoteParent || o.Sampler != nil {
+	if o.Debug {
+		span.spanContext.setIsDebug()
+	}
+	if span.spanContext.IsDebug() {
+		span.spanContext.setIsSampled(true)
+	} else if !hasParent || remoteParent || o.Sampler != nil {
 		// If this span is the child of a local span and no Sampler is set in the
 		// options, keep the parent's TraceOptions.
 		//
@@ -213,22 +268,30 @@ func startSpanInternal(name string, hasParent bool, parent SpanContext, remotePa
 			TraceID:         span.spanContext.TraceID,
 			SpanID:          span.spanContext.SpanID,
 			Name:            name,
-			HasRemoteParent: remoteParent}).Sample)
+			HasRemoteParent: remoteParent,
+			Links:           o.Links}).Sample)
 	}
 
 	if !o.RecordEvents && !span.spanContext.IsSampled() {
 		return span
 	}
 
+	startTime := o.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
 	span.data = &SpanData{
 		SpanContext:     span.spanContext,
-		StartTime:       time.Now(),
+		StartTime:       startTime,
 		Name:            name,
 		HasRemoteParent: remoteParent,
 	}
 	if hasParent {
 		span.data.ParentSpanID = parent.SpanID
 	}
+	if len(o.Links) > 0 {
+		span.data.Links = append([]Link(nil), o.Links...)
+	}
 	if o.RecordEvents {
 		var ss *spanStore
 		if o.RegisterNameForLocalSpanStore {
@@ -258,25 +321,48 @@ func EndSpan(ctx context.Context) {
 	s.End()
 }
 
+// EndSpanWithOptions ends the current span using the given options.
+//
+// Like EndSpan, the context still refers to the ended span.
+func EndSpanWithOptions(ctx context.Context, o EndOptions) {
+	s, ok := ctx.Value(contextKey{}).(*Span)
+	if !ok {
+		return
+	}
+	s.EndWithOptions(o)
+}
+
 // End ends the span.
 func (s *Span) End() {
+	s.EndWithOptions(EndOptions{})
+}
+
+// EndAt ends the span at the given time.
+func (s *Span) EndAt(t time.Time) {
+	s.EndWithOptions(EndOptions{EndTime: t})
+}
+
+// EndWithOptions ends the span using the given options.
+func (s *Span) EndWithOptions(o EndOptions) {
 	if !s.IsRecordingEvents() {
 		return
 	}
+	if o.Discard {
+		if s.spanStore != nil {
+			s.spanStore.discarded(s)
+		}
+		return
+	}
 	// TODO: optimize to avoid this call if sd won't be used.
 	sd := s.makeSpanData()
-	sd.EndTime = time.Now()
+	sd.EndTime = o.EndTime
+	if sd.EndTime.IsZero() {
+		sd.EndTime = time.Now()
+	}
 	if s.spanStore != nil {
 		s.spanStore.finished(s, sd)
 	}
-	if s.spanContext.IsSampled() {
-		// TODO: consider holding exportersMu for less time.
-		exportersMu.Lock()
-		for e := range exporters {
-			e.Export(sd)
-		}
-		exportersMu.Unlock()
-	}
+	handleEndedSpan(sd)
 }
 
 // makeSpanData produces a SpanData representing the current state of the Span.
@@ -359,6 +445,9 @@ func (s *Span) SetAttributes(attributes ...Attribute) {
 }
 
 // copyAttributes copies a slice of Attributes into a map.
+//
+// The values of array attributes are copied, so that the caller
+// can reuse them.
 func copyAttributes(m map[string]interface{}, attributes []Attribute) {
 	for _, a := range attributes {
 		switch a := a.(type) {
@@ -366,8 +455,18 @@ func copyAttributes(m map[string]interface{}, attributes []Attribute) {
 			m[a.Key] = a.Value
 		case Int64Attribute:
 			m[a.Key] = a.Value
+		case Float64Attribute:
+			m[a.Key] = a.Value
 		case StringAttribute:
 			m[a.Key] = a.Value
+		case BoolArrayAttribute:
+			m[a.Key] = append([]bool(nil), a.Value...)
+		case Int64ArrayAttribute:
+			m[a.Key] = append([]int64(nil), a.Value...)
+		case Float64ArrayAttribute:
+			m[a.Key] = append([]float64(nil), a.Value...)
+		case StringArrayAttribute:
+			m[a.Key] = append([]string(nil), a.Value...)
 		}
 	}
 }
@@ -633,6 +732,73 @@ func (s *Span) AddMessageReceiveEvent(messageID, uncompressedByteSize, compresse
 	s.mu.Unlock()
 }
 
+// AddAnnotations adds annotations to the current span.
+//
+// Unlike Print and its variants, AddAnnotations keeps the time of each
+// annotation, which allows adding annotations for events that happened
+// earlier; annotations with a zero time get the current time.
+func AddAnnotations(ctx context.Context, annotations ...Annotation) {
+	s, ok := ctx.Value(contextKey{}).(*Span)
+	if !ok {
+		return
+	}
+	s.AddAnnotations(annotations...)
+}
+
+// AddAnnotations adds annotations to the span.
+//
+// Unlike Print and its variants, AddAnnotations keeps the time of each
+// annotation, which allows adding annotations for events that happened
+// earlier; annotations with a zero time get the current time.
+// The Attributes of the annotations should not be modified afterwards.
+func (s *Span) AddAnnotations(annotations ...Annotation) {
+	if !s.IsRecordingEvents() {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	for _, a := range annotations {
+		if a.Time.IsZero() {
+			a.Time = now
+		}
+		s.data.Annotations = append(s.data.Annotations, a)
+	}
+	s.mu.Unlock()
+}
+
+// AddMessageEvents adds message events to the current span.
+//
+// Unlike AddMessageSendEvent and AddMessageReceiveEvent, AddMessageEvents
+// keeps the time of each event, which allows adding events that happened
+// earlier; events with a zero time get the current time.
+func AddMessageEvents(ctx context.Context, events ...MessageEvent) {
+	s, ok := ctx.Value(contextKey{}).(*Span)
+	if !ok {
+		return
+	}
+	s.AddMessageEvents(events...)
+}
+
+// AddMessageEvents adds message events to the span.
+//
+// Unlike AddMessageSendEvent and AddMessageReceiveEvent, AddMessageEvents
+// keeps the time of each event, which allows adding events that happened
+// earlier; events with a zero time get the current time.
+func (s *Span) AddMessageEvents(events ...MessageEvent) {
+	if !s.IsRecordingEvents() {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	for _, e := range events {
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		s.data.MessageEvents = append(s.data.MessageEvents, e)
+	}
+	s.mu.Unlock()
+}
+
 // AddLink adds a link to the current span.
 func AddLink(ctx context.Context, l Link) {
 	s, ok := ctx.Value(contextKey{}).(*Span)
@@ -16,10 +16,14 @@ package trace
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
+
+	"go.opencensus.io/stats"
 )
 
 var (
@@ -227,6 +231,39 @@ func TestSampling(t *testing.T) {
 	SetDefaultSampler(ProbabilitySampler(0)) // reset the default sampler.
 }
 
+func TestDebug(t *testing.T) {
+	ctx := StartSpanWithOptions(context.Background(), "foo", StartSpanOptions{
+		Sampler: NeverSample(),
+		Debug:   true,
+	})
+	sc, _ := SpanContextFromContext(ctx)
+	if !sc.IsSampled() || !sc.IsDebug() {
+		t.Errorf("root span: got TraceOptions %x, want sampled and debug", sc.TraceOptions)
+	}
+	child := StartSpanWithOptions(ctx, "bar", StartSpanOptions{Sampler: NeverSample()})
+	if sc, _ := SpanContextFromContext(child); !sc.IsSampled() || !sc.IsDebug() {
+		t.Errorf("child span: got TraceOptions %x, want sampled and debug", sc.TraceOptions)
+	}
+	remote := StartSpanWithRemoteParent(context.Background(), "baz", SpanContext{TraceID: tid, SpanID: sid, TraceOptions: 2}, StartSpanOptions{})
+	if sc, _ := SpanContextFromContext(remote); !sc.IsSampled() || !sc.IsDebug() {
+		t.Errorf("span with remote parent: got TraceOptions %x, want sampled and debug", sc.TraceOptions)
+	}
+
+	rules, _ := RuleSampler([]SamplingRule{{Sampler: NeverSample()}}, nil)
+	p := SamplingParameters{ParentContext: SpanContext{TraceOptions: 2}}
+	for _, s := range []Sampler{
+		NeverSample(),
+		ProbabilitySampler(0),
+		RateLimitingSampler(0.5),
+		AdaptiveSampler(0.5),
+		rules,
+	} {
+		if !s.Sample(p).Sample {
+			t.Errorf("%T: got not sampled with a debug parent, want sampled", s)
+		}
+	}
+}
+
 func TestProbabilitySampler(t *testing.T) {
 	exported := 0
 	for i := 0; i < 1000; i++ {
@@ -242,6 +279,110 @@ func TestProbabilitySampler(t *testing.T) {
 	}
 }
 
+func TestRateLimitingSampler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := RateLimitingSampler(2).(*rateLimitingSampler)
+	s.b = newTokenBucket(2, now)
+	s.now = func() time.Time { return now }
+
+	sample := func() bool { return s.Sample(SamplingParameters{Name: "foo"}).Sample }
+	for i, want := range []bool{true, true, false} {
+		if got := sample(); got != want {
+			t.Errorf("call %d: got sampled %t, want %t", i, got, want)
+		}
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !sample() {
+		t.Errorf("after refill: got not sampled, want sampled")
+	}
+	if sample() {
+		t.Errorf("after refill: got sampled twice, want once")
+	}
+	parent := SamplingParameters{ParentContext: SpanContext{TraceOptions: 1}}
+	if !s.Sample(parent).Sample {
+		t.Errorf("sampled parent: got not sampled, want sampled")
+	}
+
+	ctx := StartSpanWithOptions(context.Background(), "foo", StartSpanOptions{
+		Sampler: RateLimitingSampler(1),
+	})
+	if !IsSampled(ctx) {
+		t.Errorf("StartSpanWithOptions: got not sampled, want the first span sampled")
+	}
+}
+
+func TestAdaptiveSampler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := AdaptiveSampler(1).(*adaptiveSampler)
+	s.now = func() time.Time { return now }
+
+	count := func(name string, n int) (sampled int) {
+		for i := 0; i < n; i++ {
+			var tid TraceID
+			binary.BigEndian.PutUint64(tid[0:8], uint64(i)*(1<<63/uint64(n))<<1)
+			if s.Sample(SamplingParameters{TraceID: tid, Name: name}).Sample {
+				sampled++
+			}
+		}
+		return sampled
+	}
+
+	// In the first window, every span is sampled up to the target.
+	if got, want := count("busy", 1000), 5; got != want {
+		t.Errorf("busy, first window: got %d sampled, want %d", got, want)
+	}
+	if got, want := count("quiet", 2), 2; got != want {
+		t.Errorf("quiet, first window: got %d sampled, want %d", got, want)
+	}
+
+	// In the next window, the probability of busy reflects its rate,
+	// while quiet is still always sampled.
+	now = now.Add(adaptiveWindow)
+	if got, want := count("quiet", 2), 2; got != want {
+		t.Errorf("quiet, second window: got %d sampled, want %d", got, want)
+	}
+	if got, want := count("busy", 1000), 5; got != want {
+		t.Errorf("busy, second window: got %d sampled, want %d", got, want)
+	}
+	if got, want := s.names["busy"].probability, 0.005; got != want {
+		t.Errorf("busy probability: got %v, want %v", got, want)
+	}
+}
+
+func TestRuleSampler(t *testing.T) {
+	yes := true
+	s, err := RuleSampler([]SamplingRule{
+		{Name: "/healthz*", Sampler: NeverSample()},
+		{NameRegexp: regexp.MustCompile(`^/admin\.`), Sampler: AlwaysSample()},
+		{HasRemoteParent: &yes, Sampler: AlwaysSample()},
+	}, NeverSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p    SamplingParameters
+		want bool
+	}{
+		{SamplingParameters{Name: "/healthz"}, false},
+		{SamplingParameters{Name: "/healthz/live", HasRemoteParent: true}, true},
+		{SamplingParameters{Name: "/healthzcheck", HasRemoteParent: true}, false},
+		{SamplingParameters{Name: "/admin.Users"}, true},
+		{SamplingParameters{Name: "/api.Users"}, false},
+		{SamplingParameters{Name: "/api.Users", HasRemoteParent: true}, true},
+	} {
+		if got := s.Sample(test.p).Sample; got != test.want {
+			t.Errorf("%+v: got sampled %t, want %t", test.p, got, test.want)
+		}
+	}
+
+	if _, err := RuleSampler([]SamplingRule{{Name: "["}}, nil); err == nil {
+		t.Errorf("RuleSampler with no sampler: got no error, want an error")
+	}
+	if _, err := RuleSampler([]SamplingRule{{Name: "[", Sampler: AlwaysSample()}}, nil); err == nil {
+		t.Errorf("RuleSampler with a bad pattern: got no error, want an error")
+	}
+}
+
 func TestStartSpanWithRemoteParent(t *testing.T) {
 	sc := SpanContext{
 		TraceID:      tid,
@@ -393,6 +534,32 @@ func TestSetSpanAttributes(t *testing.T) {
 	}
 }
 
+func TestTypedAttributes(t *testing.T) {
+	ints := []int64{1, 2}
+	ctx := startSpan()
+	SetSpanAttributes(ctx,
+		Float64Attribute{"float", 1.5},
+		BoolArrayAttribute{"bools", []bool{true}},
+		Int64ArrayAttribute{"ints", ints},
+		Float64ArrayAttribute{"floats", []float64{0.5}},
+		StringArrayAttribute{"strings", []string{"a"}})
+	ints[0] = 3
+	got, err := endSpan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"float":   1.5,
+		"bools":   []bool{true},
+		"ints":    []int64{1, 2},
+		"floats":  []float64{0.5},
+		"strings": []string{"a"},
+	}
+	if !reflect.DeepEqual(got.Attributes, want) {
+		t.Errorf("exporting span: got attributes %#v want %#v", got.Attributes, want)
+	}
+}
+
 func TestAnnotations(t *testing.T) {
 	ctx := startSpan()
 	LazyPrint(ctx, foo(1))
@@ -469,6 +636,137 @@ func TestMessageEvents(t *testing.T) {
 	}
 }
 
+func TestAddEventsWithTime(t *testing.T) {
+	ctx := startSpan()
+	t1 := time.Unix(100, 0)
+	AddAnnotations(ctx,
+		Annotation{Time: t1, Message: "backfilled", Attributes: map[string]interface{}{"key": "value"}},
+		Annotation{Message: "now"})
+	AddMessageEvents(ctx,
+		MessageEvent{Time: t1, EventType: MessageEventTypeRecv, MessageID: 1, UncompressedByteSize: 10},
+		MessageEvent{EventType: MessageEventTypeSent, MessageID: 2})
+	got, err := endSpan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Annotations[0].Time.Equal(t1) || !got.MessageEvents[0].Time.Equal(t1) {
+		t.Errorf("exporting span: got times %v and %v, want %v", got.Annotations[0].Time, got.MessageEvents[0].Time, t1)
+	}
+	if !checkTime(&got.Annotations[1].Time) || !checkTime(&got.MessageEvents[1].Time) {
+		t.Error("exporting span: expected the current time for events with zero Time")
+	}
+	got.Annotations[0].Time = time.Time{}
+	got.MessageEvents[0].Time = time.Time{}
+
+	wantAnnotations := []Annotation{
+		{Message: "backfilled", Attributes: map[string]interface{}{"key": "value"}},
+		{Message: "now"},
+	}
+	if !reflect.DeepEqual(got.Annotations, wantAnnotations) {
+		t.Errorf("exporting span: got annotations %#v want %#v", got.Annotations, wantAnnotations)
+	}
+	wantEvents := []MessageEvent{
+		{EventType: MessageEventTypeRecv, MessageID: 1, UncompressedByteSize: 10},
+		{EventType: MessageEventTypeSent, MessageID: 2},
+	}
+	if !reflect.DeepEqual(got.MessageEvents, wantEvents) {
+		t.Errorf("exporting span: got message events %#v want %#v", got.MessageEvents, wantEvents)
+	}
+}
+
+func TestStartTimeAndEndAt(t *testing.T) {
+	var te testExporter
+	RegisterExporter(&te)
+	defer UnregisterExporter(&te)
+
+	start := time.Now().Add(-time.Hour)
+	end := start.Add(150 * time.Millisecond)
+	span := NewSpan("backfilled", StartSpanOptions{
+		Sampler:                       AlwaysSample(),
+		RecordEvents:                  true,
+		RegisterNameForLocalSpanStore: true,
+		StartTime:                     start,
+	})
+	span.EndAt(end)
+	Flush()
+
+	if len(te.spans) != 1 {
+		t.Fatalf("got exported spans %#v, want one span", te.spans)
+	}
+	if got := te.spans[0]; !got.StartTime.Equal(start) || !got.EndTime.Equal(end) {
+		t.Errorf("got StartTime %v and EndTime %v, want %v and %v", got.StartTime, got.EndTime, start, end)
+	}
+	if got := LatencySampledSpans("backfilled", 100*time.Millisecond, 200*time.Millisecond); len(got) != 1 {
+		t.Errorf("LatencySampledSpans: got %d spans, want 1", len(got))
+	}
+	if got := LatencySampledSpans("backfilled", time.Second, 0); len(got) != 0 {
+		t.Errorf("LatencySampledSpans over 1s: got %d spans, want 0", len(got))
+	}
+}
+
+func TestEndDiscard(t *testing.T) {
+	var te testExporter
+	RegisterExporter(&te)
+	defer UnregisterExporter(&te)
+
+	span := NewSpan("discarded", StartSpanOptions{
+		Sampler:                       AlwaysSample(),
+		RecordEvents:                  true,
+		RegisterNameForLocalSpanStore: true,
+	})
+	span.EndWithOptions(EndOptions{Discard: true})
+	Flush()
+
+	if len(te.spans) != 0 {
+		t.Errorf("got exported spans %#v, want none", te.spans)
+	}
+	if got := ActiveSpans("discarded"); len(got) != 0 {
+		t.Errorf("ActiveSpans: got %d spans, want 0", len(got))
+	}
+	if got := LatencySampledSpans("discarded", 0, 0); len(got) != 0 {
+		t.Errorf("LatencySampledSpans: got %d spans, want 0", len(got))
+	}
+}
+
+type recordingSampler struct {
+	params []SamplingParameters
+}
+
+func (s *recordingSampler) Sample(p SamplingParameters) SamplingDecision {
+	s.params = append(s.params, p)
+	return SamplingDecision{Sample: true}
+}
+
+func TestStartBatchSpan(t *testing.T) {
+	remotes := []SpanContext{
+		{TraceID: TraceID{1}, SpanID: SpanID{2}, TraceOptions: 1},
+		{TraceID: TraceID{3}, SpanID: SpanID{4}},
+	}
+	other := Link{TraceID: TraceID{5}, SpanID: SpanID{6}, Type: LinkTypeParent}
+	var s recordingSampler
+	ctx := StartBatchSpan(context.Background(), "batch", remotes, StartSpanOptions{
+		Sampler: &s,
+		Links:   []Link{other},
+	})
+	want := []Link{
+		{TraceID: TraceID{1}, SpanID: SpanID{2}, Type: LinkTypeChild},
+		{TraceID: TraceID{3}, SpanID: SpanID{4}, Type: LinkTypeChild},
+		other,
+	}
+	if len(s.params) != 1 || !reflect.DeepEqual(s.params[0].Links, want) {
+		t.Errorf("got sampling parameters %+v, want links %+v", s.params, want)
+	}
+
+	var te testExporter
+	RegisterExporter(&te)
+	EndSpan(ctx)
+	UnregisterExporter(&te)
+	if len(te.spans) != 1 || !reflect.DeepEqual(te.spans[0].Links, want) {
+		t.Errorf("got exported spans %+v, want one span with links %+v", te.spans, want)
+	}
+}
+
 func TestSetSpanStatus(t *testing.T) {
 	ctx := startSpan()
 	SetSpanStatus(ctx, Status{Code: int32(1), Message: "request failed"})
@@ -539,6 +837,188 @@ func TestUnregisterExporter(t *testing.T) {
 	}
 }
 
+type flushCloseExporter struct {
+	testExporter
+	flushed, closed int
+}
+
+func (e *flushCloseExporter) Flush() { e.flushed++ }
+
+func (e *flushCloseExporter) Close() error {
+	e.closed++
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	var e flushCloseExporter
+	RegisterExporter(&e)
+	Flush()
+	if e.flushed != 1 || e.closed != 0 {
+		t.Errorf("after Flush: flushed %d times, closed %d times; want 1, 0", e.flushed, e.closed)
+	}
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if e.flushed != 2 || e.closed != 1 {
+		t.Errorf("after Shutdown: flushed %d times, closed %d times; want 2, 1", e.flushed, e.closed)
+	}
+
+	ctx := startSpan()
+	EndSpan(ctx)
+	if len(e.spans) != 0 {
+		t.Error("Exporter was called after Shutdown")
+	}
+}
+
+type blockingExporter struct {
+	started, release chan bool
+	testExporter
+}
+
+func (e *blockingExporter) Export(s *SpanData) {
+	if len(e.spans) == 0 {
+		e.started <- true
+		<-e.release
+	}
+	e.testExporter.Export(s)
+}
+
+func TestExporterQueue(t *testing.T) {
+	e := &blockingExporter{started: make(chan bool), release: make(chan bool)}
+	RegisterExporterWithOptions(e, ExporterOptions{QueueSize: 1})
+
+	EndSpan(startSpan())
+	<-e.started
+	// The exporter is busy with the first span; the second one is
+	// queued and the third one is dropped without blocking.
+	EndSpan(startSpan())
+	EndSpan(startSpan())
+	close(e.release)
+
+	UnregisterExporter(e)
+	if got, want := len(e.spans), 2; got != want {
+		t.Errorf("got %d exported spans; want %d", got, want)
+	}
+}
+
+type slowExporter struct {
+	testExporter
+}
+
+func (e *slowExporter) Export(s *SpanData) {
+	time.Sleep(2 * time.Millisecond)
+	e.testExporter.Export(s)
+}
+
+func TestExportLatency(t *testing.T) {
+	if err := ExportLatencyView.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	defer ExportLatencyView.Unsubscribe()
+
+	e := &slowExporter{}
+	RegisterExporter(e)
+	EndSpan(startSpan())
+	UnregisterExporter(e)
+
+	rows, err := ExportLatencyView.RetrieveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == "*trace.slowExporter" {
+			found = true
+			if d := row.Data.(*stats.DistributionData); d.Count < 1 || d.Min < 2 {
+				t.Errorf("got export latencies %+v; want at least one of 2ms or more", d)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("got rows %v; want a row for the exporter", rows)
+	}
+}
+
+type stuckExporter struct {
+	blockingExporter
+}
+
+func TestExportQueueDepthWhenStuck(t *testing.T) {
+	if err := ExportQueueDepthView.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	defer ExportQueueDepthView.Unsubscribe()
+
+	e := &stuckExporter{blockingExporter{started: make(chan bool), release: make(chan bool)}}
+	RegisterExporterWithOptions(e, ExporterOptions{QueueSize: 10})
+	defer UnregisterExporter(e)
+	defer close(e.release)
+
+	EndSpan(startSpan())
+	<-e.started
+	EndSpan(startSpan())
+	EndSpan(startSpan())
+	// Let the next span record the depth without waiting for a second.
+	exportersMu.RLock()
+	eq := exporters[e]
+	exportersMu.RUnlock()
+	eq.mu.Lock()
+	eq.lastRecord = time.Time{}
+	eq.mu.Unlock()
+	EndSpan(startSpan())
+
+	rows, err := ExportQueueDepthView.RetrieveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == "*trace.stuckExporter" {
+			found = true
+			// The depth is recorded for the first span, while the exporter
+			// may not have taken it yet, and for the last one, while the
+			// exporter is stuck with the three others queued.
+			if d := row.Data.(*stats.MeanData); d.Count != 2 || d.Mean*d.Count < 3 {
+				t.Errorf("got depths %+v; want 2 of them, the last one 3", d)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("got rows %v; want a row for the exporter", rows)
+	}
+}
+
+func TestActiveSpanCount(t *testing.T) {
+	if err := ActiveSpanCountView.Subscribe(); err != nil {
+		t.Fatal(err)
+	}
+	defer ActiveSpanCountView.Unsubscribe()
+
+	// The count is recorded at most once per second for each name.
+	name := fmt.Sprintf("active span count %d", time.Now().UnixNano())
+	o := StartSpanOptions{RecordEvents: true, RegisterNameForLocalSpanStore: true}
+	ctx := StartSpanWithOptions(context.Background(), name, o)
+	defer EndSpan(ctx)
+
+	rows, err := ActiveSpanCountView.RetrieveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, row := range rows {
+		if len(row.Tags) == 1 && row.Tags[0].Value == name {
+			found = true
+			if got := row.Data.(*stats.MeanData).Mean; got != 1 {
+				t.Errorf("got %v active spans; want 1", got)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("got rows %v; want a row for the span name", rows)
+	}
+}
+
 func TestBucket(t *testing.T) {
 	// make a bucket of size 5 and add 10 spans
 	b := makeBucket(5)
@@ -590,3 +1070,123 @@ func TestBucket(t *testing.T) {
 		}
 	}
 }
+
+func TestTailSampling(t *testing.T) {
+	var te testExporter
+	RegisterExporter(&te)
+	defer UnregisterExporter(&te)
+	EnableTailSampling(TailSamplingOptions{
+		Policies:     []TailPolicy{ErrorPolicy()},
+		DecisionWait: time.Hour,
+	})
+	defer DisableTailSampling()
+
+	recorded := StartSpanOptions{RecordEvents: true, Sampler: NeverSample()}
+	failed := NewSpan("failed", recorded)
+	child := failed.StartSpanWithOptions("child", StartSpanOptions{RecordEvents: true})
+	child.SetStatus(Status{Code: 2})
+	child.End()
+	failed.End()
+	ok := NewSpan("ok", recorded)
+	ok.End()
+
+	Flush()
+	var names []string
+	for _, sd := range te.spans {
+		names = append(names, sd.Name)
+	}
+	if want := []string{"child", "failed"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got exported spans %v, want %v", names, want)
+	}
+}
+
+func TestTailSamplingSlowRoot(t *testing.T) {
+	var te testExporter
+	RegisterExporter(&te)
+	defer UnregisterExporter(&te)
+	EnableTailSampling(TailSamplingOptions{
+		Policies:     []TailPolicy{LatencyPolicy(100 * time.Millisecond)},
+		DecisionWait: 20 * time.Millisecond,
+	})
+	defer DisableTailSampling()
+
+	// The child ends long before the root, which makes the trace worth
+	// keeping once it ends after the decision wait.
+	root := NewSpan("root", StartSpanOptions{RecordEvents: true, Sampler: NeverSample()})
+	root.StartSpanWithOptions("child", StartSpanOptions{RecordEvents: true}).End()
+	time.Sleep(150 * time.Millisecond)
+	root.End()
+	// A span ending after the decision follows it.
+	late := root.StartSpanWithOptions("late", StartSpanOptions{RecordEvents: true})
+	time.Sleep(100 * time.Millisecond)
+	late.End()
+
+	Flush()
+	var names []string
+	for _, sd := range te.spans {
+		names = append(names, sd.Name)
+	}
+	if want := []string{"child", "root", "late"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got exported spans %v, want %v", names, want)
+	}
+}
+
+func TestTailSamplingBounds(t *testing.T) {
+	EnableTailSampling(TailSamplingOptions{
+		Policies:     []TailPolicy{SampledPolicy()},
+		DecisionWait: time.Hour,
+		MaxSpans:     2,
+	})
+	defer DisableTailSampling()
+	ts := tail
+	for i := 0; i < 5; i++ {
+		NewSpan("foo", StartSpanOptions{Sampler: AlwaysSample()}).End()
+	}
+	ts.mu.Lock()
+	spans, traces := ts.spans, len(ts.traces)
+	ts.mu.Unlock()
+	if spans != 2 || traces != 2 {
+		t.Errorf("got %d spans in %d traces buffered, want 2 in 2", spans, traces)
+	}
+
+	EnableTailSampling(TailSamplingOptions{DecisionWait: time.Millisecond})
+	ts = tail
+	NewSpan("foo", StartSpanOptions{Sampler: AlwaysSample()}).End()
+	for deadline := time.Now().Add(time.Second); ; {
+		ts.mu.Lock()
+		spans = ts.spans
+		ts.mu.Unlock()
+		if spans == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d spans buffered after the decision wait, want 0", spans)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTailPolicies(t *testing.T) {
+	start := time.Now()
+	sd := &SpanData{
+		StartTime:  start,
+		EndTime:    start.Add(time.Second),
+		Attributes: map[string]interface{}{"user": "alice"},
+	}
+	for _, test := range []struct {
+		name   string
+		policy TailPolicy
+		want   bool
+	}{
+		{"error", ErrorPolicy(), false},
+		{"latency under", LatencyPolicy(2 * time.Second), false},
+		{"latency over", LatencyPolicy(time.Second), true},
+		{"attribute", AttributePolicy("user", "alice"), true},
+		{"attribute mismatch", AttributePolicy("user", "bob"), false},
+		{"sampled", SampledPolicy(), false},
+	} {
+		if got := test.policy(sd); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}